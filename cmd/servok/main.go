@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"net"
 	"net/http"
@@ -9,8 +10,12 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
-	"github.com/authzed/grpcutil"
+	clustergrpc "github.com/envoyproxy/go-control-plane/envoy/service/cluster/v3"
+	discoverygrpc "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	endpointgrpc "github.com/envoyproxy/go-control-plane/envoy/service/endpoint/v3"
+	listenergrpc "github.com/envoyproxy/go-control-plane/envoy/service/listener/v3"
 	grpcmw "github.com/grpc-ecosystem/go-grpc-middleware"
 	grpczerolog "github.com/grpc-ecosystem/go-grpc-middleware/providers/zerolog/v2"
 	grpclog "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
@@ -25,9 +30,16 @@ import (
 	"google.golang.org/grpc/credentials"
 	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
 
+	"github.com/authzed/servok/internal/probe"
 	v1 "github.com/authzed/servok/internal/proto/servok/api/v1"
 	"github.com/authzed/servok/internal/services"
+	"github.com/authzed/servok/internal/sources"
+	"github.com/authzed/servok/internal/sources/endpointslice"
+	"github.com/authzed/servok/internal/sources/file"
+	"github.com/authzed/servok/internal/sources/srvrecord"
 )
 
 func main() {
@@ -45,23 +57,73 @@ func main() {
 	rootCmd.Flags().String("grpc-cert-path", "", "local path to the TLS certificate used to serve gRPC services")
 	rootCmd.Flags().String("grpc-key-path", "", "local path to the TLS key used to serve gRPC services")
 	rootCmd.Flags().Bool("grpc-no-tls", false, "serve unencrypted gRPC services")
+	rootCmd.Flags().Bool("xds-enabled", false, "serve watched targets to Envoy and gRPC xDS clients over the Aggregated Discovery Service")
 	rootCmd.Flags().String("metrics-addr", ":9090", "address to listen on for serving metrics and profiles")
+	rootCmd.Flags().Bool("health-require-resolution", false, "report the EndpointService as not serving until a watched target has resolved endpoints, rather than serving while no targets are watched")
+	rootCmd.Flags().Duration("watcher-linger", 0, "how long to keep watching a target after its last client disconnects")
+	rootCmd.Flags().Int("watch-max-skipped-updates", 0, "how many consecutive updates a slow client may skip before it is disconnected (0 for unlimited)")
+	rootCmd.Flags().Int("watch-revision-history", 16, "how many past revisions of each target are remembered so that resuming clients can be sent deltas")
+	rootCmd.Flags().Duration("watch-stale-after", 1*time.Minute, "how long a failing source serves its last known endpoints as degraded before reporting them as stale (0 to never report them as stale)")
+	rootCmd.Flags().Float64("watch-panic-threshold", 0, "percentage of a target's endpoints that one update may remove, or all of them, before it is held and the previous endpoints are served as degraded (0 to disable)")
+	rootCmd.Flags().Duration("watch-panic-grace-period", 5*time.Minute, "how long an update that exceeds the panic threshold is held before it is sent anyway (0 to hold it until the endpoints recover)")
+	rootCmd.Flags().Duration("watch-debounce", 0, "how long changes to a target's endpoints are coalesced before they are sent (0 to send them immediately)")
+	rootCmd.Flags().Duration("watch-flap-half-life", 0, "how long it takes for the penalty of an endpoint that appears or disappears to halve (0 to disable flap dampening)")
+	rootCmd.Flags().Float64("watch-flap-suppress-threshold", 3, "penalty above which a flapping endpoint is withheld from clients")
+	rootCmd.Flags().Float64("watch-flap-reuse-threshold", 1, "penalty below which a withheld endpoint is sent to clients again")
+	rootCmd.Flags().String("probe-type", "", "how discovered endpoints are health checked: tcp, grpc or http (empty to disable health checking)")
+	rootCmd.Flags().Duration("probe-interval", 10*time.Second, "how often each discovered endpoint is health checked")
+	rootCmd.Flags().Duration("probe-timeout", 1*time.Second, "how long each health check may take before the endpoint is considered unhealthy")
+	rootCmd.Flags().Int("probe-unhealthy-threshold", 3, "how many consecutive health checks an endpoint must fail to become unhealthy")
+	rootCmd.Flags().Int("probe-healthy-threshold", 2, "how many consecutive health checks an unhealthy endpoint must pass to become healthy again")
+	rootCmd.Flags().String("probe-grpc-service", "", "service whose status the grpc health check requests (empty for the server as a whole)")
+	rootCmd.Flags().String("probe-http-path", "/healthz", "path that the http health check requests")
+	rootCmd.Flags().Bool("probe-filter-unhealthy", true, "remove unhealthy endpoints from responses rather than marking them as neither ready nor serving")
+	rootCmd.Flags().Duration("srv-poll-interval", 1*time.Second, "how often DNS SRV records are resolved when a request doesn't specify an interval")
+	rootCmd.Flags().Duration("srv-min-poll-interval", 100*time.Millisecond, "the shortest DNS SRV poll interval that requests may specify")
+	rootCmd.Flags().Duration("srv-max-poll-interval", 1*time.Minute, "the longest DNS SRV poll interval that requests may specify")
+	rootCmd.Flags().Duration("srv-retry-initial-backoff", 1*time.Second, "how long to wait before retrying a failed DNS SRV lookup")
+	rootCmd.Flags().Duration("srv-retry-max-backoff", 30*time.Second, "the longest to wait between retries of consecutive failed DNS SRV lookups")
+	rootCmd.Flags().StringSlice("srv-nameservers", nil, "nameservers to query in order for DNS SRV records, which report TTLs (defaults to the system resolver)")
+	rootCmd.Flags().String("srv-dns-transport", srvrecord.TransportUDP, "transport used to query the SRV nameservers: udp, tcp, tls or https (which takes URLs as nameservers)")
+	rootCmd.Flags().Duration("srv-dns-timeout", 2*time.Second, "how long to wait for each SRV nameserver to answer before trying the next one")
+	rootCmd.Flags().String("srv-dns-tls-server-name", "", "server name used to verify the SRV nameservers' certificates over tls and https (defaults to their hostnames)")
+	rootCmd.Flags().Bool("srv-ttl-scheduling", false, "re-resolve DNS SRV records when their TTLs expire rather than at the poll interval")
+	rootCmd.Flags().Duration("srv-ttl-min", 1*time.Second, "the shortest delay between TTL scheduled DNS SRV lookups")
+	rootCmd.Flags().Duration("srv-ttl-max", 5*time.Minute, "the longest delay between TTL scheduled DNS SRV lookups")
+	rootCmd.Flags().Float64("srv-ttl-jitter", 0.1, "the fraction by which TTL scheduled DNS SRV lookups are randomly varied")
+	rootCmd.Flags().String("file-source-dir", "", "local path to a directory of YAML or JSON files to serve endpoints from")
+	rootCmd.Flags().Duration("file-source-period", 1*time.Second, "how often files in the file source directory are checked for changes")
+	rootCmd.Flags().Bool("kubernetes-enabled", false, "serve endpoints from Kubernetes EndpointSlices")
+	rootCmd.Flags().String("kubernetes-kubeconfig", "", "local path to the kubeconfig used to watch Kubernetes (defaults to in-cluster config)")
 
 	cobrautil.RegisterZeroLogFlags(rootCmd.Flags())
 
 	_ = rootCmd.Execute()
 }
 
+// interceptorOptions returns the interceptors that trace, measure, log and
+// validate every RPC, whether unary or streaming.
+func interceptorOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpcmw.WithUnaryServerChain(
+			otelgrpc.UnaryServerInterceptor(),
+			grpcprom.UnaryServerInterceptor,
+			grpclog.UnaryServerInterceptor(grpczerolog.InterceptorLogger(log.Logger)),
+			validator.UnaryServerInterceptor(),
+		),
+		grpcmw.WithStreamServerChain(
+			otelgrpc.StreamServerInterceptor(),
+			grpcprom.StreamServerInterceptor,
+			grpclog.StreamServerInterceptor(grpczerolog.InterceptorLogger(log.Logger)),
+			validator.StreamServerInterceptor(),
+		),
+	}
+}
+
 func rootRun(cmd *cobra.Command, args []string) {
 	ctx, cancel := context.WithCancel(context.Background())
 
-	var sharedOptions []grpc.ServerOption
-	sharedOptions = append(sharedOptions, grpcmw.WithUnaryServerChain(
-		otelgrpc.UnaryServerInterceptor(),
-		grpcprom.UnaryServerInterceptor,
-		grpclog.UnaryServerInterceptor(grpczerolog.InterceptorLogger(log.Logger)),
-		validator.UnaryServerInterceptor(),
-	))
+	sharedOptions := interceptorOptions()
 
 	var grpcServer *grpc.Server
 	if cobrautil.MustGetBool(cmd, "grpc-no-tls") {
@@ -78,20 +140,124 @@ func rootRun(cmd *cobra.Command, args []string) {
 		}
 	}
 
-	healthSrv := grpcutil.NewAuthlessHealthServer()
+	healthSrv := services.NewHealthServer()
 
 	healthpb.RegisterHealthServer(grpcServer, healthSrv)
-	healthSrv.SetServingStatus(
-		v1.EndpointService_ServiceDesc.ServiceName,
-		healthpb.HealthCheckResponse_SERVING,
-	)
 
-	servicer, err := services.NewEndpointServicer(ctx)
+	var resolver srvrecord.Resolver = srvrecord.SystemResolver{}
+	if nameservers := cobrautil.MustGetStringSlice(cmd, "srv-nameservers"); len(nameservers) > 0 {
+		var err error
+		resolver, err = srvrecord.NewDNSResolver(srvrecord.DNSResolverOptions{
+			Nameservers: nameservers,
+			Transport:   cobrautil.MustGetString(cmd, "srv-dns-transport"),
+			Timeout:     cobrautil.MustGetDuration(cmd, "srv-dns-timeout"),
+			TLSConfig:   &tls.Config{ServerName: cobrautil.MustGetString(cmd, "srv-dns-tls-server-name")},
+		})
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to create DNS resolver")
+		}
+	}
+
+	backoff := srvrecord.Backoff{
+		Initial: cobrautil.MustGetDuration(cmd, "srv-retry-initial-backoff"),
+		Max:     cobrautil.MustGetDuration(cmd, "srv-retry-max-backoff"),
+	}
+	if err := backoff.Validate(); err != nil {
+		log.Fatal().Err(err).Msg("invalid DNS SRV retry backoff")
+	}
+
+	ttl := srvrecord.TTLSchedule{
+		Enabled: cobrautil.MustGetBool(cmd, "srv-ttl-scheduling"),
+		Min:     cobrautil.MustGetDuration(cmd, "srv-ttl-min"),
+		Max:     cobrautil.MustGetDuration(cmd, "srv-ttl-max"),
+		Jitter:  cobrautil.MustGetFloat64(cmd, "srv-ttl-jitter"),
+	}
+	if err := ttl.Validate(); err != nil {
+		log.Fatal().Err(err).Msg("invalid DNS SRV TTL schedule")
+	}
+
+	srvKind := &srvrecord.Kind{
+		UpdatePeriod:    cobrautil.MustGetDuration(cmd, "srv-poll-interval"),
+		MinUpdatePeriod: cobrautil.MustGetDuration(cmd, "srv-min-poll-interval"),
+		MaxUpdatePeriod: cobrautil.MustGetDuration(cmd, "srv-max-poll-interval"),
+		Backoff:         backoff,
+		Resolver:        resolver,
+		TTL:             ttl,
+	}
+	if err := srvKind.ValidatePollIntervals(); err != nil {
+		log.Fatal().Err(err).Msg("invalid DNS SRV poll interval")
+	}
+
+	registry := sources.NewRegistry()
+	registry.Register("srv", srvKind)
+
+	if dir := cobrautil.MustGetStringExpanded(cmd, "file-source-dir"); dir != "" {
+		period := cobrautil.MustGetDuration(cmd, "file-source-period")
+		if period <= 0 {
+			log.Fatal().Stringer("period", period).Msg("file source period must be positive")
+		}
+		registry.Register("file", &file.Kind{
+			Dir:          dir,
+			UpdatePeriod: period,
+		})
+	}
+
+	if cobrautil.MustGetBool(cmd, "kubernetes-enabled") {
+		client, err := NewKubernetesClient(cobrautil.MustGetStringExpanded(cmd, "kubernetes-kubeconfig"))
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to create Kubernetes client")
+		}
+		registry.Register("kubernetes", &endpointslice.Kind{Client: client})
+	}
+
+	var prober probe.Prober
+	if probeType := cobrautil.MustGetString(cmd, "probe-type"); probeType != "" {
+		var err error
+		prober, err = probe.New(probeType, cobrautil.MustGetString(cmd, "probe-grpc-service"), cobrautil.MustGetString(cmd, "probe-http-path"))
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to create endpoint prober")
+		}
+	}
+
+	servicer, err := services.NewEndpointServicer(ctx, registry, services.Options{
+		WatcherLinger:         cobrautil.MustGetDuration(cmd, "watcher-linger"),
+		MaxSkippedUpdates:     cobrautil.MustGetInt(cmd, "watch-max-skipped-updates"),
+		RevisionHistory:       cobrautil.MustGetInt(cmd, "watch-revision-history"),
+		StaleAfter:            cobrautil.MustGetDuration(cmd, "watch-stale-after"),
+		PanicThreshold:        cobrautil.MustGetFloat64(cmd, "watch-panic-threshold"),
+		PanicGracePeriod:      cobrautil.MustGetDuration(cmd, "watch-panic-grace-period"),
+		Debounce:              cobrautil.MustGetDuration(cmd, "watch-debounce"),
+		FlapHalfLife:          cobrautil.MustGetDuration(cmd, "watch-flap-half-life"),
+		FlapSuppressThreshold: cobrautil.MustGetFloat64(cmd, "watch-flap-suppress-threshold"),
+		FlapReuseThreshold:    cobrautil.MustGetFloat64(cmd, "watch-flap-reuse-threshold"),
+		Probes: services.ProbeOptions{
+			Prober:             prober,
+			Interval:           cobrautil.MustGetDuration(cmd, "probe-interval"),
+			Timeout:            cobrautil.MustGetDuration(cmd, "probe-timeout"),
+			UnhealthyThreshold: cobrautil.MustGetInt(cmd, "probe-unhealthy-threshold"),
+			HealthyThreshold:   cobrautil.MustGetInt(cmd, "probe-healthy-threshold"),
+			FilterUnhealthy:    cobrautil.MustGetBool(cmd, "probe-filter-unhealthy"),
+		},
+		Health:                  healthSrv,
+		HealthRequireResolution: cobrautil.MustGetBool(cmd, "health-require-resolution"),
+	})
 	if err != nil {
 		log.Fatal().Err(err).Msg("unable to initialize servicer")
 	}
 	v1.RegisterEndpointServiceServer(grpcServer, servicer)
+
+	if cobrautil.MustGetBool(cmd, "xds-enabled") {
+		xdsServer, err := services.NewXDSServer(ctx, servicer)
+		if err != nil {
+			log.Fatal().Err(err).Msg("unable to initialize xDS server")
+		}
+		discoverygrpc.RegisterAggregatedDiscoveryServiceServer(grpcServer, xdsServer)
+		listenergrpc.RegisterListenerDiscoveryServiceServer(grpcServer, xdsServer)
+		clustergrpc.RegisterClusterDiscoveryServiceServer(grpcServer, xdsServer)
+		endpointgrpc.RegisterEndpointDiscoveryServiceServer(grpcServer, xdsServer)
+	}
 	reflection.Register(grpcServer)
+	grpcprom.Register(grpcServer)
 
 	go func() {
 		addr := cobrautil.MustGetString(cmd, "grpc-addr")
@@ -117,6 +283,10 @@ func rootRun(cmd *cobra.Command, args []string) {
 	<-signalctx.Done()
 
 	log.Info().Msg("shutting down")
+
+	// Health checks fail while connections drain, and keep failing as the
+	// watchers shut down.
+	healthSrv.Shutdown()
 	cancel()
 	grpcServer.GracefulStop()
 
@@ -153,3 +323,12 @@ func NewTLSGrpcServer(certPath, keyPath string, opts ...grpc.ServerOption) (*grp
 	opts = append(opts, grpc.Creds(creds))
 	return grpc.NewServer(opts...), nil
 }
+
+func NewKubernetesClient(kubeconfigPath string) (kubernetes.Interface, error) {
+	config, err := clientcmd.BuildConfigFromFlags("", kubeconfigPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return kubernetes.NewForConfig(config)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	v1 "github.com/authzed/servok/internal/proto/servok/api/v1"
+	"github.com/authzed/servok/internal/services"
+	"github.com/authzed/servok/internal/services/servicestest"
+	"github.com/authzed/servok/internal/sources"
+)
+
+func newInterceptedClient(t *testing.T) v1.EndpointServiceClient {
+	kind := &servicestest.Kind{Endpoints: []*v1.Endpoint{{Hostname: "host1", Port: 50051}}}
+	conn := servicestest.Dial(t, kind, func(ctx context.Context, registry *sources.Registry, server *grpc.Server) error {
+		servicer, err := services.NewEndpointServicer(ctx, registry, services.Options{})
+		if err != nil {
+			return err
+		}
+		v1.RegisterEndpointServiceServer(server, servicer)
+		return nil
+	}, interceptorOptions()...)
+	return v1.NewEndpointServiceClient(conn)
+}
+
+func srvRequest(srv *v1.WatchRequest_SRVRequest) *v1.WatchRequest {
+	return &v1.WatchRequest{RequestTypeOneof: &v1.WatchRequest_Srv{Srv: srv}}
+}
+
+func TestWatchValidation(t *testing.T) {
+	client := newInterceptedClient(t)
+
+	testCases := []struct {
+		name     string
+		request  *v1.WatchRequest
+		expected codes.Code
+	}{
+		{
+			"valid",
+			srvRequest(&v1.WatchRequest_SRVRequest{Service: "grpc", Protocol: "tcp", DnsName: "example.com"}),
+			codes.OK,
+		},
+		{
+			"missing endpoint type",
+			&v1.WatchRequest{},
+			codes.InvalidArgument,
+		},
+		{
+			"missing srv request",
+			srvRequest(nil),
+			codes.InvalidArgument,
+		},
+		{
+			"invalid service",
+			srvRequest(&v1.WatchRequest_SRVRequest{Service: "gRPC!", Protocol: "tcp", DnsName: "example.com"}),
+			codes.InvalidArgument,
+		},
+		{
+			"invalid protocol",
+			srvRequest(&v1.WatchRequest_SRVRequest{Service: "grpc", Protocol: "sctp", DnsName: "example.com"}),
+			codes.InvalidArgument,
+		},
+		{
+			"empty dns name",
+			srvRequest(&v1.WatchRequest_SRVRequest{Service: "grpc", Protocol: "tcp"}),
+			codes.InvalidArgument,
+		},
+		{
+			"undefined priority tiers",
+			srvRequest(&v1.WatchRequest_SRVRequest{Service: "grpc", Protocol: "tcp", DnsName: "example.com", PriorityTiers: 42}),
+			codes.InvalidArgument,
+		},
+		{
+			"negative poll interval",
+			srvRequest(&v1.WatchRequest_SRVRequest{Service: "grpc", Protocol: "tcp", DnsName: "example.com", PollInterval: durationpb.New(-1 * time.Second)}),
+			codes.InvalidArgument,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+			defer cancel()
+
+			stream, err := client.Watch(ctx, tc.request)
+			require.NoError(t, err)
+			_, err = stream.Recv()
+			require.Equal(t, tc.expected, status.Code(err), "unexpected error: %v", err)
+
+			_, err = client.GetEndpoints(ctx, &v1.GetEndpointsRequest{Target: tc.request})
+			require.Equal(t, tc.expected, status.Code(err), "unexpected error: %v", err)
+		})
+	}
+}
@@ -4,6 +4,7 @@ go 1.16
 
 require (
 	github.com/authzed/grpcutil v0.0.0-20210709212005-3a705ca91827
+	github.com/envoyproxy/go-control-plane v0.9.10-0.20210907150352-cf90f659a021
 	github.com/envoyproxy/protoc-gen-validate v0.6.1
 	github.com/google/go-cmp v0.5.6
 	github.com/grpc-ecosystem/go-grpc-middleware v1.3.0
@@ -12,6 +13,7 @@ require (
 	github.com/grpc-ecosystem/go-grpc-prometheus v1.2.0
 	github.com/jzelinskie/cobrautil v0.0.5
 	github.com/jzelinskie/stringz v0.0.1 // indirect
+	github.com/miekg/dns v1.1.43
 	github.com/prometheus/client_golang v0.9.4
 	github.com/rs/zerolog v1.25.0
 	github.com/spf13/cobra v1.2.1
@@ -20,4 +22,8 @@ require (
 	go.opentelemetry.io/otel/exporters/jaeger v1.0.0 // indirect
 	google.golang.org/grpc v1.41.0
 	google.golang.org/protobuf v1.27.1
+	k8s.io/api v0.22.2
+	k8s.io/apimachinery v0.22.2
+	k8s.io/client-go v0.22.2
+	sigs.k8s.io/yaml v1.2.0
 )
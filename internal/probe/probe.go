@@ -0,0 +1,122 @@
+// Package probe checks whether endpoints are accepting traffic.
+package probe
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	v1 "github.com/authzed/servok/internal/proto/servok/api/v1"
+)
+
+// Prober checks the health of an endpoint, returning why it is unhealthy. The
+// context bounds how long the check may take.
+type Prober interface {
+	Probe(ctx context.Context, endpoint *v1.Endpoint) error
+}
+
+// New returns the prober of the given type, which is one of "tcp", "grpc" or
+// "http". The gRPC prober checks the service, which may be empty to check the
+// server as a whole, and the HTTP prober requests the path.
+func New(probeType, grpcService, httpPath string) (Prober, error) {
+	switch probeType {
+	case "tcp":
+		return TCP{}, nil
+	case "grpc":
+		return GRPC{Service: grpcService}, nil
+	case "http":
+		return HTTP{Path: httpPath}, nil
+	default:
+		return nil, fmt.Errorf("unknown probe type %q", probeType)
+	}
+}
+
+// Address returns the address that an endpoint is probed at, which is its
+// first resolved address, if any, and otherwise its hostname.
+func Address(endpoint *v1.Endpoint) string {
+	host := endpoint.Hostname
+	if len(endpoint.Addresses) > 0 {
+		host = endpoint.Addresses[0]
+	}
+	return net.JoinHostPort(host, strconv.FormatUint(uint64(endpoint.Port), 10))
+}
+
+// TCP considers endpoints healthy when they accept connections.
+type TCP struct{}
+
+func (TCP) Probe(ctx context.Context, endpoint *v1.Endpoint) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", Address(endpoint))
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// GRPC considers endpoints healthy when they report Service as serving with
+// the gRPC health checking protocol.
+type GRPC struct {
+	Service string
+}
+
+func (p GRPC) Probe(ctx context.Context, endpoint *v1.Endpoint) error {
+	conn, err := grpc.DialContext(ctx, Address(endpoint), grpc.WithInsecure(), grpc.WithBlock())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: p.Service})
+	if err != nil {
+		return err
+	}
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("service %q is %s", p.Service, resp.Status)
+	}
+	return nil
+}
+
+// HTTP considers endpoints healthy when a GET request for Path succeeds with
+// a 2xx status. Redirects are not followed, so they fail the probe.
+type HTTP struct {
+	Path string
+}
+
+// httpClient probes every endpoint over a new direct connection, ignoring any
+// proxy configured by the environment, so that it is the endpoint itself that
+// is checked.
+var httpClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy:             nil,
+		DisableKeepAlives: true,
+	},
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+func (p HTTP) Probe(ctx context.Context, endpoint *v1.Endpoint) error {
+	url := "http://" + Address(endpoint) + p.Path
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Host = net.JoinHostPort(strings.TrimSuffix(endpoint.Hostname, "."), strconv.FormatUint(uint64(endpoint.Port), 10))
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("GET %s returned %s", p.Path, resp.Status)
+	}
+	return nil
+}
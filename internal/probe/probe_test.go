@@ -0,0 +1,133 @@
+package probe
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	v1 "github.com/authzed/servok/internal/proto/servok/api/v1"
+)
+
+// endpointFor returns an endpoint for a local listener's address.
+func endpointFor(t *testing.T, addr string) *v1.Endpoint {
+	host, port, err := net.SplitHostPort(addr)
+	require.NoError(t, err)
+	portNumber, err := strconv.ParseUint(port, 10, 16)
+	require.NoError(t, err)
+	return &v1.Endpoint{Hostname: "localhost", Port: uint32(portNumber), Addresses: []string{host}}
+}
+
+// closedEndpoint returns an endpoint that refuses connections.
+func closedEndpoint(t *testing.T) *v1.Endpoint {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	endpoint := endpointFor(t, listener.Addr().String())
+	require.NoError(t, listener.Close())
+	return endpoint
+}
+
+func probe(prober Prober, endpoint *v1.Endpoint) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	return prober.Probe(ctx, endpoint)
+}
+
+func TestAddress(t *testing.T) {
+	require.Equal(t, "host1.example.com.:50051", Address(&v1.Endpoint{Hostname: "host1.example.com.", Port: 50051}))
+	require.Equal(t, "10.0.0.1:50051", Address(&v1.Endpoint{Hostname: "host1.example.com.", Port: 50051, Addresses: []string{"10.0.0.1", "10.0.0.2"}}))
+	require.Equal(t, "[::1]:50051", Address(&v1.Endpoint{Hostname: "host1.example.com.", Port: 50051, Addresses: []string{"::1"}}))
+}
+
+func TestTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	require.NoError(t, probe(TCP{}, endpointFor(t, listener.Addr().String())))
+	require.Error(t, probe(TCP{}, closedEndpoint(t)))
+}
+
+func TestGRPC(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	healthSrv := health.NewServer()
+	healthSrv.SetServingStatus("servok.test.Serving", healthpb.HealthCheckResponse_SERVING)
+	healthSrv.SetServingStatus("servok.test.NotServing", healthpb.HealthCheckResponse_NOT_SERVING)
+	server := grpc.NewServer()
+	healthpb.RegisterHealthServer(server, healthSrv)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer server.Stop()
+	endpoint := endpointFor(t, listener.Addr().String())
+
+	require.NoError(t, probe(GRPC{}, endpoint))
+	require.NoError(t, probe(GRPC{Service: "servok.test.Serving"}, endpoint))
+	require.EqualError(t, probe(GRPC{Service: "servok.test.NotServing"}, endpoint), `service "servok.test.NotServing" is NOT_SERVING`)
+	require.Error(t, probe(GRPC{Service: "servok.test.Unknown"}, endpoint))
+	require.Error(t, probe(GRPC{}, closedEndpoint(t)))
+}
+
+func TestHTTP(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/healthz":
+		case "/moved":
+			http.Redirect(w, r, "/healthz", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	var conns int32
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+	endpoint := endpointFor(t, server.Listener.Addr().String())
+
+	require.NoError(t, probe(HTTP{Path: "/healthz"}, endpoint))
+	require.EqualError(t, probe(HTTP{Path: "/ready"}, endpoint), "GET /ready returned 503 Service Unavailable")
+	require.EqualError(t, probe(HTTP{Path: "/moved"}, endpoint), "GET /moved returned 302 Found")
+	require.Error(t, probe(HTTP{Path: "/healthz"}, closedEndpoint(t)))
+
+	// Every probe makes a new connection
+	require.Equal(t, int32(3), atomic.LoadInt32(&conns))
+}
+
+func TestNew(t *testing.T) {
+	testCases := []struct {
+		probeType string
+		expected  Prober
+	}{
+		{"tcp", TCP{}},
+		{"grpc", GRPC{Service: "servok.test"}},
+		{"http", HTTP{Path: "/healthz"}},
+		{"icmp", nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.probeType, func(t *testing.T) {
+			prober, err := New(tc.probeType, "servok.test", "/healthz")
+			if tc.expected == nil {
+				require.EqualError(t, err, `unknown probe type "icmp"`)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, prober)
+		})
+	}
+}
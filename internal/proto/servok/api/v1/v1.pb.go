@@ -10,6 +10,8 @@ import (
 	_ "github.com/envoyproxy/protoc-gen-validate/validate"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
 )
@@ -21,33 +23,943 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// PriorityTiers selects which RFC 2782 priority tiers are returned.
+type WatchRequest_SRVRequest_PriorityTiers int32
+
+const (
+	// PRIORITY_TIERS_ALL returns the records of every tier.
+	WatchRequest_SRVRequest_PRIORITY_TIERS_ALL WatchRequest_SRVRequest_PriorityTiers = 0
+	// PRIORITY_TIERS_LOWEST only returns the records with the lowest
+	// priority value, which are the ones clients must prefer.
+	WatchRequest_SRVRequest_PRIORITY_TIERS_LOWEST WatchRequest_SRVRequest_PriorityTiers = 1
+)
+
+// Enum value maps for WatchRequest_SRVRequest_PriorityTiers.
+var (
+	WatchRequest_SRVRequest_PriorityTiers_name = map[int32]string{
+		0: "PRIORITY_TIERS_ALL",
+		1: "PRIORITY_TIERS_LOWEST",
+	}
+	WatchRequest_SRVRequest_PriorityTiers_value = map[string]int32{
+		"PRIORITY_TIERS_ALL":    0,
+		"PRIORITY_TIERS_LOWEST": 1,
+	}
+)
+
+func (x WatchRequest_SRVRequest_PriorityTiers) Enum() *WatchRequest_SRVRequest_PriorityTiers {
+	p := new(WatchRequest_SRVRequest_PriorityTiers)
+	*p = x
+	return p
+}
+
+func (x WatchRequest_SRVRequest_PriorityTiers) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (WatchRequest_SRVRequest_PriorityTiers) Descriptor() protoreflect.EnumDescriptor {
+	return file_servok_api_v1_v1_proto_enumTypes[0].Descriptor()
+}
+
+func (WatchRequest_SRVRequest_PriorityTiers) Type() protoreflect.EnumType {
+	return &file_servok_api_v1_v1_proto_enumTypes[0]
+}
+
+func (x WatchRequest_SRVRequest_PriorityTiers) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use WatchRequest_SRVRequest_PriorityTiers.Descriptor instead.
+func (WatchRequest_SRVRequest_PriorityTiers) EnumDescriptor() ([]byte, []int) {
+	return file_servok_api_v1_v1_proto_rawDescGZIP(), []int{0, 0, 0}
+}
+
+// ResponseType describes how a response relates to the previous one.
+type WatchResponse_ResponseType int32
+
+const (
+	// RESPONSE_TYPE_SNAPSHOT responses carry every endpoint in endpoints,
+	// replacing any that were previously received.
+	WatchResponse_RESPONSE_TYPE_SNAPSHOT WatchResponse_ResponseType = 0
+	// RESPONSE_TYPE_DELTA responses carry the endpoints that were added,
+	// removed or modified since the previous response. Endpoints are
+	// identified by their hostname and port.
+	WatchResponse_RESPONSE_TYPE_DELTA WatchResponse_ResponseType = 1
+)
+
+// Enum value maps for WatchResponse_ResponseType.
+var (
+	WatchResponse_ResponseType_name = map[int32]string{
+		0: "RESPONSE_TYPE_SNAPSHOT",
+		1: "RESPONSE_TYPE_DELTA",
+	}
+	WatchResponse_ResponseType_value = map[string]int32{
+		"RESPONSE_TYPE_SNAPSHOT": 0,
+		"RESPONSE_TYPE_DELTA":    1,
+	}
+)
+
+func (x WatchResponse_ResponseType) Enum() *WatchResponse_ResponseType {
+	p := new(WatchResponse_ResponseType)
+	*p = x
+	return p
+}
+
+func (x WatchResponse_ResponseType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (WatchResponse_ResponseType) Descriptor() protoreflect.EnumDescriptor {
+	return file_servok_api_v1_v1_proto_enumTypes[1].Descriptor()
+}
+
+func (WatchResponse_ResponseType) Type() protoreflect.EnumType {
+	return &file_servok_api_v1_v1_proto_enumTypes[1]
+}
+
+func (x WatchResponse_ResponseType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use WatchResponse_ResponseType.Descriptor instead.
+func (WatchResponse_ResponseType) EnumDescriptor() ([]byte, []int) {
+	return file_servok_api_v1_v1_proto_rawDescGZIP(), []int{1, 0}
+}
+
+// SourceStatus describes whether the endpoints are current.
+type WatchResponse_SourceStatus int32
+
+const (
+	// SOURCE_STATUS_OK endpoints were resolved by the latest refresh of the
+	// source.
+	WatchResponse_SOURCE_STATUS_OK WatchResponse_SourceStatus = 0
+	// SOURCE_STATUS_DEGRADED endpoints are the last known ones, which keep
+	// being served while the source fails to refresh them.
+	WatchResponse_SOURCE_STATUS_DEGRADED WatchResponse_SourceStatus = 1
+	// SOURCE_STATUS_STALE endpoints are the last known ones, and the source
+	// has been failing for longer than the server considers them current.
+	WatchResponse_SOURCE_STATUS_STALE WatchResponse_SourceStatus = 2
+	// SOURCE_STATUS_ERROR responses carry no endpoints, because the source
+	// has failed without ever resolving any, or, in WatchMany, because the
+	// target is no longer watched. Clients should keep using any endpoints
+	// they already know of.
+	WatchResponse_SOURCE_STATUS_ERROR WatchResponse_SourceStatus = 3
+)
+
+// Enum value maps for WatchResponse_SourceStatus.
+var (
+	WatchResponse_SourceStatus_name = map[int32]string{
+		0: "SOURCE_STATUS_OK",
+		1: "SOURCE_STATUS_DEGRADED",
+		2: "SOURCE_STATUS_STALE",
+		3: "SOURCE_STATUS_ERROR",
+	}
+	WatchResponse_SourceStatus_value = map[string]int32{
+		"SOURCE_STATUS_OK":       0,
+		"SOURCE_STATUS_DEGRADED": 1,
+		"SOURCE_STATUS_STALE":    2,
+		"SOURCE_STATUS_ERROR":    3,
+	}
+)
+
+func (x WatchResponse_SourceStatus) Enum() *WatchResponse_SourceStatus {
+	p := new(WatchResponse_SourceStatus)
+	*p = x
+	return p
+}
+
+func (x WatchResponse_SourceStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (WatchResponse_SourceStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_servok_api_v1_v1_proto_enumTypes[2].Descriptor()
+}
+
+func (WatchResponse_SourceStatus) Type() protoreflect.EnumType {
+	return &file_servok_api_v1_v1_proto_enumTypes[2]
+}
+
+func (x WatchResponse_SourceStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use WatchResponse_SourceStatus.Descriptor instead.
+func (WatchResponse_SourceStatus) EnumDescriptor() ([]byte, []int) {
+	return file_servok_api_v1_v1_proto_rawDescGZIP(), []int{1, 1}
+}
+
 type WatchRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	// Types that are assignable to RequestTypeOneof:
-	//	*WatchRequest_Srv
-	RequestTypeOneof isWatchRequest_RequestTypeOneof `protobuf_oneof:"request_type_oneof"`
+	// Types that are assignable to RequestTypeOneof:
+	//	*WatchRequest_Srv
+	//	*WatchRequest_Kubernetes
+	//	*WatchRequest_File
+	RequestTypeOneof isWatchRequest_RequestTypeOneof `protobuf_oneof:"request_type_oneof"`
+	// delta requests that responses after the initial snapshot only carry the
+	// endpoints that changed since the previous response.
+	Delta bool `protobuf:"varint,4,opt,name=delta,proto3" json:"delta,omitempty"`
+	// last_seen_revision resumes a watch from the last revision the client
+	// received. The server skips the initial snapshot if the endpoints have
+	// not changed since, and otherwise sends a delta when it still remembers
+	// that revision and the client requested deltas.
+	LastSeenRevision uint64 `protobuf:"varint,5,opt,name=last_seen_revision,json=lastSeenRevision,proto3" json:"last_seen_revision,omitempty"`
+}
+
+func (x *WatchRequest) Reset() {
+	*x = WatchRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servok_api_v1_v1_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRequest) ProtoMessage() {}
+
+func (x *WatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_servok_api_v1_v1_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRequest.ProtoReflect.Descriptor instead.
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return file_servok_api_v1_v1_proto_rawDescGZIP(), []int{0}
+}
+
+func (m *WatchRequest) GetRequestTypeOneof() isWatchRequest_RequestTypeOneof {
+	if m != nil {
+		return m.RequestTypeOneof
+	}
+	return nil
+}
+
+func (x *WatchRequest) GetSrv() *WatchRequest_SRVRequest {
+	if x, ok := x.GetRequestTypeOneof().(*WatchRequest_Srv); ok {
+		return x.Srv
+	}
+	return nil
+}
+
+func (x *WatchRequest) GetKubernetes() *WatchRequest_KubernetesRequest {
+	if x, ok := x.GetRequestTypeOneof().(*WatchRequest_Kubernetes); ok {
+		return x.Kubernetes
+	}
+	return nil
+}
+
+func (x *WatchRequest) GetFile() *WatchRequest_FileRequest {
+	if x, ok := x.GetRequestTypeOneof().(*WatchRequest_File); ok {
+		return x.File
+	}
+	return nil
+}
+
+func (x *WatchRequest) GetDelta() bool {
+	if x != nil {
+		return x.Delta
+	}
+	return false
+}
+
+func (x *WatchRequest) GetLastSeenRevision() uint64 {
+	if x != nil {
+		return x.LastSeenRevision
+	}
+	return 0
+}
+
+type isWatchRequest_RequestTypeOneof interface {
+	isWatchRequest_RequestTypeOneof()
+}
+
+type WatchRequest_Srv struct {
+	Srv *WatchRequest_SRVRequest `protobuf:"bytes,1,opt,name=srv,proto3,oneof"`
+}
+
+type WatchRequest_Kubernetes struct {
+	Kubernetes *WatchRequest_KubernetesRequest `protobuf:"bytes,2,opt,name=kubernetes,proto3,oneof"`
+}
+
+type WatchRequest_File struct {
+	File *WatchRequest_FileRequest `protobuf:"bytes,3,opt,name=file,proto3,oneof"`
+}
+
+func (*WatchRequest_Srv) isWatchRequest_RequestTypeOneof() {}
+
+func (*WatchRequest_Kubernetes) isWatchRequest_RequestTypeOneof() {}
+
+func (*WatchRequest_File) isWatchRequest_RequestTypeOneof() {}
+
+type WatchResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Endpoints []*Endpoint `protobuf:"bytes,1,rep,name=endpoints,proto3" json:"endpoints,omitempty"`
+	// revision increases with every change to the watched endpoints. It is
+	// only meaningful to the server that sent it.
+	Revision     uint64                     `protobuf:"varint,2,opt,name=revision,proto3" json:"revision,omitempty"`
+	ResponseType WatchResponse_ResponseType `protobuf:"varint,3,opt,name=response_type,json=responseType,proto3,enum=servok.api.v1.WatchResponse_ResponseType" json:"response_type,omitempty"`
+	Added        []*Endpoint                `protobuf:"bytes,4,rep,name=added,proto3" json:"added,omitempty"`
+	Removed      []*Endpoint                `protobuf:"bytes,5,rep,name=removed,proto3" json:"removed,omitempty"`
+	Modified     []*Endpoint                `protobuf:"bytes,6,rep,name=modified,proto3" json:"modified,omitempty"`
+	SourceStatus WatchResponse_SourceStatus `protobuf:"varint,7,opt,name=source_status,json=sourceStatus,proto3,enum=servok.api.v1.WatchResponse_SourceStatus" json:"source_status,omitempty"`
+	// last_successful_update is when the source last refreshed the endpoints,
+	// and is unset if it never has.
+	LastSuccessfulUpdate *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=last_successful_update,json=lastSuccessfulUpdate,proto3" json:"last_successful_update,omitempty"`
+	// source_error describes why the source is failing when its status is not
+	// SOURCE_STATUS_OK.
+	SourceError string `protobuf:"bytes,9,opt,name=source_error,json=sourceError,proto3" json:"source_error,omitempty"`
+}
+
+func (x *WatchResponse) Reset() {
+	*x = WatchResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servok_api_v1_v1_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchResponse) ProtoMessage() {}
+
+func (x *WatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_servok_api_v1_v1_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchResponse.ProtoReflect.Descriptor instead.
+func (*WatchResponse) Descriptor() ([]byte, []int) {
+	return file_servok_api_v1_v1_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *WatchResponse) GetEndpoints() []*Endpoint {
+	if x != nil {
+		return x.Endpoints
+	}
+	return nil
+}
+
+func (x *WatchResponse) GetRevision() uint64 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+func (x *WatchResponse) GetResponseType() WatchResponse_ResponseType {
+	if x != nil {
+		return x.ResponseType
+	}
+	return WatchResponse_RESPONSE_TYPE_SNAPSHOT
+}
+
+func (x *WatchResponse) GetAdded() []*Endpoint {
+	if x != nil {
+		return x.Added
+	}
+	return nil
+}
+
+func (x *WatchResponse) GetRemoved() []*Endpoint {
+	if x != nil {
+		return x.Removed
+	}
+	return nil
+}
+
+func (x *WatchResponse) GetModified() []*Endpoint {
+	if x != nil {
+		return x.Modified
+	}
+	return nil
+}
+
+func (x *WatchResponse) GetSourceStatus() WatchResponse_SourceStatus {
+	if x != nil {
+		return x.SourceStatus
+	}
+	return WatchResponse_SOURCE_STATUS_OK
+}
+
+func (x *WatchResponse) GetLastSuccessfulUpdate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastSuccessfulUpdate
+	}
+	return nil
+}
+
+func (x *WatchResponse) GetSourceError() string {
+	if x != nil {
+		return x.SourceError
+	}
+	return ""
+}
+
+type WatchManyRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Targets []*WatchRequest `protobuf:"bytes,1,rep,name=targets,proto3" json:"targets,omitempty"`
+}
+
+func (x *WatchManyRequest) Reset() {
+	*x = WatchManyRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servok_api_v1_v1_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WatchManyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchManyRequest) ProtoMessage() {}
+
+func (x *WatchManyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_servok_api_v1_v1_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchManyRequest.ProtoReflect.Descriptor instead.
+func (*WatchManyRequest) Descriptor() ([]byte, []int) {
+	return file_servok_api_v1_v1_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *WatchManyRequest) GetTargets() []*WatchRequest {
+	if x != nil {
+		return x.Targets
+	}
+	return nil
+}
+
+// WatchManyResponse carries a response for one of the targets. A target whose
+// watch ends, because its source closed or the client fell too far behind on
+// it, is sent a final response with SOURCE_STATUS_ERROR and a source_error
+// saying why, and is no longer watched while the other targets are. The
+// stream ends once every target's watch has.
+type WatchManyResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// target_index is the position in WatchManyRequest.targets of the target
+	// that the response belongs to.
+	TargetIndex uint32         `protobuf:"varint,1,opt,name=target_index,json=targetIndex,proto3" json:"target_index,omitempty"`
+	Response    *WatchResponse `protobuf:"bytes,2,opt,name=response,proto3" json:"response,omitempty"`
+}
+
+func (x *WatchManyResponse) Reset() {
+	*x = WatchManyResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servok_api_v1_v1_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WatchManyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchManyResponse) ProtoMessage() {}
+
+func (x *WatchManyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_servok_api_v1_v1_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchManyResponse.ProtoReflect.Descriptor instead.
+func (*WatchManyResponse) Descriptor() ([]byte, []int) {
+	return file_servok_api_v1_v1_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *WatchManyResponse) GetTargetIndex() uint32 {
+	if x != nil {
+		return x.TargetIndex
+	}
+	return 0
+}
+
+func (x *WatchManyResponse) GetResponse() *WatchResponse {
+	if x != nil {
+		return x.Response
+	}
+	return nil
+}
+
+type GetEndpointsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// target is the endpoints to get, as they would be watched. Fields that
+	// only apply to streams, such as delta, are ignored.
+	Target *WatchRequest `protobuf:"bytes,1,opt,name=target,proto3" json:"target,omitempty"`
+}
+
+func (x *GetEndpointsRequest) Reset() {
+	*x = GetEndpointsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servok_api_v1_v1_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetEndpointsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetEndpointsRequest) ProtoMessage() {}
+
+func (x *GetEndpointsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_servok_api_v1_v1_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetEndpointsRequest.ProtoReflect.Descriptor instead.
+func (*GetEndpointsRequest) Descriptor() ([]byte, []int) {
+	return file_servok_api_v1_v1_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetEndpointsRequest) GetTarget() *WatchRequest {
+	if x != nil {
+		return x.Target
+	}
+	return nil
+}
+
+type GetEndpointsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Endpoints []*Endpoint `protobuf:"bytes,1,rep,name=endpoints,proto3" json:"endpoints,omitempty"`
+	// revision is the revision of the endpoints when they were served by an
+	// active watch, and zero when they were resolved for this request.
+	Revision             uint64                     `protobuf:"varint,2,opt,name=revision,proto3" json:"revision,omitempty"`
+	SourceStatus         WatchResponse_SourceStatus `protobuf:"varint,3,opt,name=source_status,json=sourceStatus,proto3,enum=servok.api.v1.WatchResponse_SourceStatus" json:"source_status,omitempty"`
+	LastSuccessfulUpdate *timestamppb.Timestamp     `protobuf:"bytes,4,opt,name=last_successful_update,json=lastSuccessfulUpdate,proto3" json:"last_successful_update,omitempty"`
+	SourceError          string                     `protobuf:"bytes,5,opt,name=source_error,json=sourceError,proto3" json:"source_error,omitempty"`
+}
+
+func (x *GetEndpointsResponse) Reset() {
+	*x = GetEndpointsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servok_api_v1_v1_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetEndpointsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetEndpointsResponse) ProtoMessage() {}
+
+func (x *GetEndpointsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_servok_api_v1_v1_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetEndpointsResponse.ProtoReflect.Descriptor instead.
+func (*GetEndpointsResponse) Descriptor() ([]byte, []int) {
+	return file_servok_api_v1_v1_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetEndpointsResponse) GetEndpoints() []*Endpoint {
+	if x != nil {
+		return x.Endpoints
+	}
+	return nil
+}
+
+func (x *GetEndpointsResponse) GetRevision() uint64 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+func (x *GetEndpointsResponse) GetSourceStatus() WatchResponse_SourceStatus {
+	if x != nil {
+		return x.SourceStatus
+	}
+	return WatchResponse_SOURCE_STATUS_OK
+}
+
+func (x *GetEndpointsResponse) GetLastSuccessfulUpdate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastSuccessfulUpdate
+	}
+	return nil
+}
+
+func (x *GetEndpointsResponse) GetSourceError() string {
+	if x != nil {
+		return x.SourceError
+	}
+	return ""
+}
+
+type ListWatchesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ListWatchesRequest) Reset() {
+	*x = ListWatchesRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servok_api_v1_v1_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListWatchesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListWatchesRequest) ProtoMessage() {}
+
+func (x *ListWatchesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_servok_api_v1_v1_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListWatchesRequest.ProtoReflect.Descriptor instead.
+func (*ListWatchesRequest) Descriptor() ([]byte, []int) {
+	return file_servok_api_v1_v1_proto_rawDescGZIP(), []int{6}
+}
+
+type ListWatchesResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Watches []*ListWatchesResponse_Watch `protobuf:"bytes,1,rep,name=watches,proto3" json:"watches,omitempty"`
+}
+
+func (x *ListWatchesResponse) Reset() {
+	*x = ListWatchesResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servok_api_v1_v1_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListWatchesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListWatchesResponse) ProtoMessage() {}
+
+func (x *ListWatchesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_servok_api_v1_v1_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListWatchesResponse.ProtoReflect.Descriptor instead.
+func (*ListWatchesResponse) Descriptor() ([]byte, []int) {
+	return file_servok_api_v1_v1_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ListWatchesResponse) GetWatches() []*ListWatchesResponse_Watch {
+	if x != nil {
+		return x.Watches
+	}
+	return nil
+}
+
+type Endpoint struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Hostname string `protobuf:"bytes,1,opt,name=hostname,proto3" json:"hostname,omitempty"`
+	Port     uint32 `protobuf:"varint,2,opt,name=port,proto3" json:"port,omitempty"`
+	Weight   uint32 `protobuf:"varint,3,opt,name=weight,proto3" json:"weight,omitempty"`
+	// conditions are only set by sources that track the readiness of
+	// individual endpoints.
+	Conditions *Endpoint_Conditions `protobuf:"bytes,4,opt,name=conditions,proto3" json:"conditions,omitempty"`
+	Priority   uint32               `protobuf:"varint,5,opt,name=priority,proto3" json:"priority,omitempty"`
+	// addresses are the IP addresses of the hostname, when requested.
+	Addresses []string `protobuf:"bytes,6,rep,name=addresses,proto3" json:"addresses,omitempty"`
+}
+
+func (x *Endpoint) Reset() {
+	*x = Endpoint{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servok_api_v1_v1_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Endpoint) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Endpoint) ProtoMessage() {}
+
+func (x *Endpoint) ProtoReflect() protoreflect.Message {
+	mi := &file_servok_api_v1_v1_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Endpoint.ProtoReflect.Descriptor instead.
+func (*Endpoint) Descriptor() ([]byte, []int) {
+	return file_servok_api_v1_v1_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Endpoint) GetHostname() string {
+	if x != nil {
+		return x.Hostname
+	}
+	return ""
+}
+
+func (x *Endpoint) GetPort() uint32 {
+	if x != nil {
+		return x.Port
+	}
+	return 0
+}
+
+func (x *Endpoint) GetWeight() uint32 {
+	if x != nil {
+		return x.Weight
+	}
+	return 0
+}
+
+func (x *Endpoint) GetConditions() *Endpoint_Conditions {
+	if x != nil {
+		return x.Conditions
+	}
+	return nil
+}
+
+func (x *Endpoint) GetPriority() uint32 {
+	if x != nil {
+		return x.Priority
+	}
+	return 0
+}
+
+func (x *Endpoint) GetAddresses() []string {
+	if x != nil {
+		return x.Addresses
+	}
+	return nil
+}
+
+type WatchRequest_SRVRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Service       string                                `protobuf:"bytes,1,opt,name=service,proto3" json:"service,omitempty"`
+	Protocol      string                                `protobuf:"bytes,2,opt,name=protocol,proto3" json:"protocol,omitempty"`
+	DnsName       string                                `protobuf:"bytes,3,opt,name=dns_name,json=dnsName,proto3" json:"dns_name,omitempty"`
+	PriorityTiers WatchRequest_SRVRequest_PriorityTiers `protobuf:"varint,4,opt,name=priority_tiers,json=priorityTiers,proto3,enum=servok.api.v1.WatchRequest_SRVRequest_PriorityTiers" json:"priority_tiers,omitempty"`
+	// resolve_addresses looks up the IPv4 and IPv6 addresses of each SRV
+	// target and returns them with its endpoint.
+	ResolveAddresses bool `protobuf:"varint,5,opt,name=resolve_addresses,json=resolveAddresses,proto3" json:"resolve_addresses,omitempty"`
+	// poll_interval is how often the SRV records are resolved. It is clamped
+	// to the bounds configured by the server, which uses its default when
+	// it is not set. Clients that share a target are refreshed at the
+	// fastest interval any of them requested.
+	PollInterval *durationpb.Duration `protobuf:"bytes,6,opt,name=poll_interval,json=pollInterval,proto3" json:"poll_interval,omitempty"`
+}
+
+func (x *WatchRequest_SRVRequest) Reset() {
+	*x = WatchRequest_SRVRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servok_api_v1_v1_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WatchRequest_SRVRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRequest_SRVRequest) ProtoMessage() {}
+
+func (x *WatchRequest_SRVRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_servok_api_v1_v1_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRequest_SRVRequest.ProtoReflect.Descriptor instead.
+func (*WatchRequest_SRVRequest) Descriptor() ([]byte, []int) {
+	return file_servok_api_v1_v1_proto_rawDescGZIP(), []int{0, 0}
+}
+
+func (x *WatchRequest_SRVRequest) GetService() string {
+	if x != nil {
+		return x.Service
+	}
+	return ""
+}
+
+func (x *WatchRequest_SRVRequest) GetProtocol() string {
+	if x != nil {
+		return x.Protocol
+	}
+	return ""
+}
+
+func (x *WatchRequest_SRVRequest) GetDnsName() string {
+	if x != nil {
+		return x.DnsName
+	}
+	return ""
+}
+
+func (x *WatchRequest_SRVRequest) GetPriorityTiers() WatchRequest_SRVRequest_PriorityTiers {
+	if x != nil {
+		return x.PriorityTiers
+	}
+	return WatchRequest_SRVRequest_PRIORITY_TIERS_ALL
+}
+
+func (x *WatchRequest_SRVRequest) GetResolveAddresses() bool {
+	if x != nil {
+		return x.ResolveAddresses
+	}
+	return false
+}
+
+func (x *WatchRequest_SRVRequest) GetPollInterval() *durationpb.Duration {
+	if x != nil {
+		return x.PollInterval
+	}
+	return nil
+}
+
+type WatchRequest_KubernetesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Namespace   string `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	ServiceName string `protobuf:"bytes,2,opt,name=service_name,json=serviceName,proto3" json:"service_name,omitempty"`
+	// port_name selects a named port of the service; it may be omitted when
+	// the service only exposes a single, unnamed port.
+	PortName string `protobuf:"bytes,3,opt,name=port_name,json=portName,proto3" json:"port_name,omitempty"`
+	// include_unready also returns endpoints that are not ready, such as
+	// those that are still starting up or are terminating.
+	IncludeUnready bool `protobuf:"varint,4,opt,name=include_unready,json=includeUnready,proto3" json:"include_unready,omitempty"`
 }
 
-func (x *WatchRequest) Reset() {
-	*x = WatchRequest{}
+func (x *WatchRequest_KubernetesRequest) Reset() {
+	*x = WatchRequest_KubernetesRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_servok_api_v1_v1_proto_msgTypes[0]
+		mi := &file_servok_api_v1_v1_proto_msgTypes[10]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *WatchRequest) String() string {
+func (x *WatchRequest_KubernetesRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*WatchRequest) ProtoMessage() {}
+func (*WatchRequest_KubernetesRequest) ProtoMessage() {}
 
-func (x *WatchRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_servok_api_v1_v1_proto_msgTypes[0]
+func (x *WatchRequest_KubernetesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_servok_api_v1_v1_proto_msgTypes[10]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -58,60 +970,66 @@ func (x *WatchRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use WatchRequest.ProtoReflect.Descriptor instead.
-func (*WatchRequest) Descriptor() ([]byte, []int) {
-	return file_servok_api_v1_v1_proto_rawDescGZIP(), []int{0}
+// Deprecated: Use WatchRequest_KubernetesRequest.ProtoReflect.Descriptor instead.
+func (*WatchRequest_KubernetesRequest) Descriptor() ([]byte, []int) {
+	return file_servok_api_v1_v1_proto_rawDescGZIP(), []int{0, 1}
 }
 
-func (m *WatchRequest) GetRequestTypeOneof() isWatchRequest_RequestTypeOneof {
-	if m != nil {
-		return m.RequestTypeOneof
+func (x *WatchRequest_KubernetesRequest) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
 	}
-	return nil
+	return ""
 }
 
-func (x *WatchRequest) GetSrv() *WatchRequest_SRVRequest {
-	if x, ok := x.GetRequestTypeOneof().(*WatchRequest_Srv); ok {
-		return x.Srv
+func (x *WatchRequest_KubernetesRequest) GetServiceName() string {
+	if x != nil {
+		return x.ServiceName
 	}
-	return nil
+	return ""
 }
 
-type isWatchRequest_RequestTypeOneof interface {
-	isWatchRequest_RequestTypeOneof()
+func (x *WatchRequest_KubernetesRequest) GetPortName() string {
+	if x != nil {
+		return x.PortName
+	}
+	return ""
 }
 
-type WatchRequest_Srv struct {
-	Srv *WatchRequest_SRVRequest `protobuf:"bytes,1,opt,name=srv,proto3,oneof"`
+func (x *WatchRequest_KubernetesRequest) GetIncludeUnready() bool {
+	if x != nil {
+		return x.IncludeUnready
+	}
+	return false
 }
 
-func (*WatchRequest_Srv) isWatchRequest_RequestTypeOneof() {}
-
-type WatchResponse struct {
+type WatchRequest_FileRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Endpoints []*Endpoint `protobuf:"bytes,1,rep,name=endpoints,proto3" json:"endpoints,omitempty"`
+	// name is the name of a file within the server's configured source
+	// directory.
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
 }
 
-func (x *WatchResponse) Reset() {
-	*x = WatchResponse{}
+func (x *WatchRequest_FileRequest) Reset() {
+	*x = WatchRequest_FileRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_servok_api_v1_v1_proto_msgTypes[1]
+		mi := &file_servok_api_v1_v1_proto_msgTypes[11]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *WatchResponse) String() string {
+func (x *WatchRequest_FileRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*WatchResponse) ProtoMessage() {}
+func (*WatchRequest_FileRequest) ProtoMessage() {}
 
-func (x *WatchResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_servok_api_v1_v1_proto_msgTypes[1]
+func (x *WatchRequest_FileRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_servok_api_v1_v1_proto_msgTypes[11]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -122,45 +1040,49 @@ func (x *WatchResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use WatchResponse.ProtoReflect.Descriptor instead.
-func (*WatchResponse) Descriptor() ([]byte, []int) {
-	return file_servok_api_v1_v1_proto_rawDescGZIP(), []int{1}
+// Deprecated: Use WatchRequest_FileRequest.ProtoReflect.Descriptor instead.
+func (*WatchRequest_FileRequest) Descriptor() ([]byte, []int) {
+	return file_servok_api_v1_v1_proto_rawDescGZIP(), []int{0, 2}
 }
 
-func (x *WatchResponse) GetEndpoints() []*Endpoint {
+func (x *WatchRequest_FileRequest) GetName() string {
 	if x != nil {
-		return x.Endpoints
+		return x.Name
 	}
-	return nil
+	return ""
 }
 
-type Endpoint struct {
+type ListWatchesResponse_Watch struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Hostname string `protobuf:"bytes,1,opt,name=hostname,proto3" json:"hostname,omitempty"`
-	Port     uint32 `protobuf:"varint,2,opt,name=port,proto3" json:"port,omitempty"`
-	Weight   uint32 `protobuf:"varint,3,opt,name=weight,proto3" json:"weight,omitempty"`
+	// key identifies the target, and is shared by all requests for it.
+	Key     string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Clients uint32 `protobuf:"varint,2,opt,name=clients,proto3" json:"clients,omitempty"`
+	// revision and endpoints are zero until the first endpoints of the
+	// target have been received.
+	Revision  uint64 `protobuf:"varint,3,opt,name=revision,proto3" json:"revision,omitempty"`
+	Endpoints uint32 `protobuf:"varint,4,opt,name=endpoints,proto3" json:"endpoints,omitempty"`
 }
 
-func (x *Endpoint) Reset() {
-	*x = Endpoint{}
+func (x *ListWatchesResponse_Watch) Reset() {
+	*x = ListWatchesResponse_Watch{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_servok_api_v1_v1_proto_msgTypes[2]
+		mi := &file_servok_api_v1_v1_proto_msgTypes[12]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *Endpoint) String() string {
+func (x *ListWatchesResponse_Watch) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Endpoint) ProtoMessage() {}
+func (*ListWatchesResponse_Watch) ProtoMessage() {}
 
-func (x *Endpoint) ProtoReflect() protoreflect.Message {
-	mi := &file_servok_api_v1_v1_proto_msgTypes[2]
+func (x *ListWatchesResponse_Watch) ProtoReflect() protoreflect.Message {
+	mi := &file_servok_api_v1_v1_proto_msgTypes[12]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -171,59 +1093,66 @@ func (x *Endpoint) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Endpoint.ProtoReflect.Descriptor instead.
-func (*Endpoint) Descriptor() ([]byte, []int) {
-	return file_servok_api_v1_v1_proto_rawDescGZIP(), []int{2}
+// Deprecated: Use ListWatchesResponse_Watch.ProtoReflect.Descriptor instead.
+func (*ListWatchesResponse_Watch) Descriptor() ([]byte, []int) {
+	return file_servok_api_v1_v1_proto_rawDescGZIP(), []int{7, 0}
 }
 
-func (x *Endpoint) GetHostname() string {
+func (x *ListWatchesResponse_Watch) GetKey() string {
 	if x != nil {
-		return x.Hostname
+		return x.Key
 	}
 	return ""
 }
 
-func (x *Endpoint) GetPort() uint32 {
+func (x *ListWatchesResponse_Watch) GetClients() uint32 {
 	if x != nil {
-		return x.Port
+		return x.Clients
 	}
 	return 0
 }
 
-func (x *Endpoint) GetWeight() uint32 {
+func (x *ListWatchesResponse_Watch) GetRevision() uint64 {
 	if x != nil {
-		return x.Weight
+		return x.Revision
 	}
 	return 0
 }
 
-type WatchRequest_SRVRequest struct {
+func (x *ListWatchesResponse_Watch) GetEndpoints() uint32 {
+	if x != nil {
+		return x.Endpoints
+	}
+	return 0
+}
+
+type Endpoint_Conditions struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Service  string `protobuf:"bytes,1,opt,name=service,proto3" json:"service,omitempty"`
-	Protocol string `protobuf:"bytes,2,opt,name=protocol,proto3" json:"protocol,omitempty"`
-	DnsName  string `protobuf:"bytes,3,opt,name=dns_name,json=dnsName,proto3" json:"dns_name,omitempty"`
+	Ready       bool `protobuf:"varint,1,opt,name=ready,proto3" json:"ready,omitempty"`
+	Serving     bool `protobuf:"varint,2,opt,name=serving,proto3" json:"serving,omitempty"`
+	Terminating bool `protobuf:"varint,3,opt,name=terminating,proto3" json:"terminating,omitempty"`
 }
 
-func (x *WatchRequest_SRVRequest) Reset() {
-	*x = WatchRequest_SRVRequest{}
+func (x *Endpoint_Conditions) Reset() {
+	*x = Endpoint_Conditions{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_servok_api_v1_v1_proto_msgTypes[3]
+		mi := &file_servok_api_v1_v1_proto_msgTypes[13]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *WatchRequest_SRVRequest) String() string {
+func (x *Endpoint_Conditions) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*WatchRequest_SRVRequest) ProtoMessage() {}
+func (*Endpoint_Conditions) ProtoMessage() {}
 
-func (x *WatchRequest_SRVRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_servok_api_v1_v1_proto_msgTypes[3]
+func (x *Endpoint_Conditions) ProtoReflect() protoreflect.Message {
+	mi := &file_servok_api_v1_v1_proto_msgTypes[13]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -234,30 +1163,30 @@ func (x *WatchRequest_SRVRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use WatchRequest_SRVRequest.ProtoReflect.Descriptor instead.
-func (*WatchRequest_SRVRequest) Descriptor() ([]byte, []int) {
-	return file_servok_api_v1_v1_proto_rawDescGZIP(), []int{0, 0}
+// Deprecated: Use Endpoint_Conditions.ProtoReflect.Descriptor instead.
+func (*Endpoint_Conditions) Descriptor() ([]byte, []int) {
+	return file_servok_api_v1_v1_proto_rawDescGZIP(), []int{8, 0}
 }
 
-func (x *WatchRequest_SRVRequest) GetService() string {
+func (x *Endpoint_Conditions) GetReady() bool {
 	if x != nil {
-		return x.Service
+		return x.Ready
 	}
-	return ""
+	return false
 }
 
-func (x *WatchRequest_SRVRequest) GetProtocol() string {
+func (x *Endpoint_Conditions) GetServing() bool {
 	if x != nil {
-		return x.Protocol
+		return x.Serving
 	}
-	return ""
+	return false
 }
 
-func (x *WatchRequest_SRVRequest) GetDnsName() string {
+func (x *Endpoint_Conditions) GetTerminating() bool {
 	if x != nil {
-		return x.DnsName
+		return x.Terminating
 	}
-	return ""
+	return false
 }
 
 var File_servok_api_v1_v1_proto protoreflect.FileDescriptor
@@ -265,56 +1194,238 @@ var File_servok_api_v1_v1_proto protoreflect.FileDescriptor
 var file_servok_api_v1_v1_proto_rawDesc = []byte{
 	0x0a, 0x16, 0x73, 0x65, 0x72, 0x76, 0x6f, 0x6b, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x76, 0x31, 0x2f,
 	0x76, 0x31, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0d, 0x73, 0x65, 0x72, 0x76, 0x6f, 0x6b,
-	0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x1a, 0x17, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74,
-	0x65, 0x2f, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x22, 0xcd, 0x02, 0x0a, 0x0c, 0x57, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
-	0x74, 0x12, 0x44, 0x0a, 0x03, 0x73, 0x72, 0x76, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x26,
-	0x2e, 0x73, 0x65, 0x72, 0x76, 0x6f, 0x6b, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x57,
-	0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x2e, 0x53, 0x52, 0x56, 0x52,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x42, 0x08, 0xfa, 0x42, 0x05, 0x8a, 0x01, 0x02, 0x10, 0x01,
-	0x48, 0x00, 0x52, 0x03, 0x73, 0x72, 0x76, 0x1a, 0xdb, 0x01, 0x0a, 0x0a, 0x53, 0x52, 0x56, 0x52,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x4b, 0x0a, 0x07, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63,
-	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x42, 0x31, 0xfa, 0x42, 0x2e, 0x72, 0x2c, 0x28, 0xfd,
-	0x01, 0x32, 0x27, 0x5e, 0x5b, 0x61, 0x2d, 0x7a, 0x30, 0x2d, 0x39, 0x5d, 0x28, 0x5b, 0x61, 0x2d,
-	0x7a, 0x30, 0x2d, 0x39, 0x2d, 0x5c, 0x2e, 0x5d, 0x7b, 0x30, 0x2c, 0x32, 0x35, 0x31, 0x7d, 0x5b,
-	0x61, 0x2d, 0x7a, 0x30, 0x2d, 0x39, 0x5d, 0x29, 0x3f, 0x24, 0x52, 0x07, 0x73, 0x65, 0x72, 0x76,
-	0x69, 0x63, 0x65, 0x12, 0x32, 0x0a, 0x08, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x18,
-	0x02, 0x20, 0x01, 0x28, 0x09, 0x42, 0x16, 0xfa, 0x42, 0x13, 0x72, 0x11, 0x32, 0x0f, 0x5e, 0x28,
-	0x28, 0x74, 0x63, 0x70, 0x29, 0x7c, 0x28, 0x75, 0x64, 0x70, 0x29, 0x29, 0x24, 0x52, 0x08, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x12, 0x4c, 0x0a, 0x08, 0x64, 0x6e, 0x73, 0x5f, 0x6e,
-	0x61, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x42, 0x31, 0xfa, 0x42, 0x2e, 0x72, 0x2c,
-	0x28, 0xfd, 0x01, 0x32, 0x27, 0x5e, 0x5b, 0x61, 0x2d, 0x7a, 0x30, 0x2d, 0x39, 0x5d, 0x28, 0x5b,
-	0x61, 0x2d, 0x7a, 0x30, 0x2d, 0x39, 0x2d, 0x5c, 0x2e, 0x5d, 0x7b, 0x30, 0x2c, 0x32, 0x35, 0x31,
-	0x7d, 0x5b, 0x61, 0x2d, 0x7a, 0x30, 0x2d, 0x39, 0x5d, 0x29, 0x3f, 0x24, 0x52, 0x07, 0x64, 0x6e,
-	0x73, 0x4e, 0x61, 0x6d, 0x65, 0x42, 0x19, 0x0a, 0x12, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
-	0x5f, 0x74, 0x79, 0x70, 0x65, 0x5f, 0x6f, 0x6e, 0x65, 0x6f, 0x66, 0x12, 0x03, 0xf8, 0x42, 0x01,
-	0x22, 0x46, 0x0a, 0x0d, 0x57, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
-	0x65, 0x12, 0x35, 0x0a, 0x09, 0x65, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x18, 0x01,
-	0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x6f, 0x6b, 0x2e, 0x61, 0x70,
-	0x69, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x52, 0x09, 0x65,
-	0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x22, 0x52, 0x0a, 0x08, 0x45, 0x6e, 0x64, 0x70,
-	0x6f, 0x69, 0x6e, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x68, 0x6f, 0x73, 0x74, 0x6e, 0x61, 0x6d, 0x65,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x68, 0x6f, 0x73, 0x74, 0x6e, 0x61, 0x6d, 0x65,
-	0x12, 0x12, 0x0a, 0x04, 0x70, 0x6f, 0x72, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x04,
-	0x70, 0x6f, 0x72, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x77, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x03,
-	0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x77, 0x65, 0x69, 0x67, 0x68, 0x74, 0x32, 0x59, 0x0a, 0x0f,
-	0x45, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12,
-	0x46, 0x0a, 0x05, 0x57, 0x61, 0x74, 0x63, 0x68, 0x12, 0x1b, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x6f,
+	0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x1a, 0x1e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61,
+	0x6d, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x17, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x61,
+	0x74, 0x65, 0x2f, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x22, 0xd1, 0x09, 0x0a, 0x0c, 0x57, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x44, 0x0a, 0x03, 0x73, 0x72, 0x76, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x26, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x6f, 0x6b, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e,
+	0x57, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x2e, 0x53, 0x52, 0x56,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x42, 0x08, 0xfa, 0x42, 0x05, 0x8a, 0x01, 0x02, 0x10,
+	0x01, 0x48, 0x00, 0x52, 0x03, 0x73, 0x72, 0x76, 0x12, 0x59, 0x0a, 0x0a, 0x6b, 0x75, 0x62, 0x65,
+	0x72, 0x6e, 0x65, 0x74, 0x65, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x2d, 0x2e, 0x73,
+	0x65, 0x72, 0x76, 0x6f, 0x6b, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x57, 0x61, 0x74,
+	0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x2e, 0x4b, 0x75, 0x62, 0x65, 0x72, 0x6e,
+	0x65, 0x74, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x42, 0x08, 0xfa, 0x42, 0x05,
+	0x8a, 0x01, 0x02, 0x10, 0x01, 0x48, 0x00, 0x52, 0x0a, 0x6b, 0x75, 0x62, 0x65, 0x72, 0x6e, 0x65,
+	0x74, 0x65, 0x73, 0x12, 0x47, 0x0a, 0x04, 0x66, 0x69, 0x6c, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x27, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x6f, 0x6b, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76,
+	0x31, 0x2e, 0x57, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x2e, 0x46,
+	0x69, 0x6c, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x42, 0x08, 0xfa, 0x42, 0x05, 0x8a,
+	0x01, 0x02, 0x10, 0x01, 0x48, 0x00, 0x52, 0x04, 0x66, 0x69, 0x6c, 0x65, 0x12, 0x14, 0x0a, 0x05,
+	0x64, 0x65, 0x6c, 0x74, 0x61, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x05, 0x64, 0x65, 0x6c,
+	0x74, 0x61, 0x12, 0x2c, 0x0a, 0x12, 0x6c, 0x61, 0x73, 0x74, 0x5f, 0x73, 0x65, 0x65, 0x6e, 0x5f,
+	0x72, 0x65, 0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x05, 0x20, 0x01, 0x28, 0x04, 0x52, 0x10,
+	0x6c, 0x61, 0x73, 0x74, 0x53, 0x65, 0x65, 0x6e, 0x52, 0x65, 0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e,
+	0x1a, 0xfd, 0x03, 0x0a, 0x0a, 0x53, 0x52, 0x56, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x4b, 0x0a, 0x07, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x42, 0x31, 0xfa, 0x42, 0x2e, 0x72, 0x2c, 0x28, 0xfd, 0x01, 0x32, 0x27, 0x5e, 0x5b, 0x61, 0x2d,
+	0x7a, 0x30, 0x2d, 0x39, 0x5d, 0x28, 0x5b, 0x61, 0x2d, 0x7a, 0x30, 0x2d, 0x39, 0x2d, 0x5c, 0x2e,
+	0x5d, 0x7b, 0x30, 0x2c, 0x32, 0x35, 0x31, 0x7d, 0x5b, 0x61, 0x2d, 0x7a, 0x30, 0x2d, 0x39, 0x5d,
+	0x29, 0x3f, 0x24, 0x52, 0x07, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x32, 0x0a, 0x08,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x42, 0x16,
+	0xfa, 0x42, 0x13, 0x72, 0x11, 0x32, 0x0f, 0x5e, 0x28, 0x28, 0x74, 0x63, 0x70, 0x29, 0x7c, 0x28,
+	0x75, 0x64, 0x70, 0x29, 0x29, 0x24, 0x52, 0x08, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c,
+	0x12, 0x4c, 0x0a, 0x08, 0x64, 0x6e, 0x73, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x09, 0x42, 0x31, 0xfa, 0x42, 0x2e, 0x72, 0x2c, 0x28, 0xfd, 0x01, 0x32, 0x27, 0x5e, 0x5b,
+	0x61, 0x2d, 0x7a, 0x30, 0x2d, 0x39, 0x5d, 0x28, 0x5b, 0x61, 0x2d, 0x7a, 0x30, 0x2d, 0x39, 0x2d,
+	0x5c, 0x2e, 0x5d, 0x7b, 0x30, 0x2c, 0x32, 0x35, 0x31, 0x7d, 0x5b, 0x61, 0x2d, 0x7a, 0x30, 0x2d,
+	0x39, 0x5d, 0x29, 0x3f, 0x24, 0x52, 0x07, 0x64, 0x6e, 0x73, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x65,
+	0x0a, 0x0e, 0x70, 0x72, 0x69, 0x6f, 0x72, 0x69, 0x74, 0x79, 0x5f, 0x74, 0x69, 0x65, 0x72, 0x73,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x34, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x6f, 0x6b, 0x2e,
+	0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x57, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x2e, 0x53, 0x52, 0x56, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x2e, 0x50,
+	0x72, 0x69, 0x6f, 0x72, 0x69, 0x74, 0x79, 0x54, 0x69, 0x65, 0x72, 0x73, 0x42, 0x08, 0xfa, 0x42,
+	0x05, 0x82, 0x01, 0x02, 0x10, 0x01, 0x52, 0x0d, 0x70, 0x72, 0x69, 0x6f, 0x72, 0x69, 0x74, 0x79,
+	0x54, 0x69, 0x65, 0x72, 0x73, 0x12, 0x2b, 0x0a, 0x11, 0x72, 0x65, 0x73, 0x6f, 0x6c, 0x76, 0x65,
+	0x5f, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x65, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x10, 0x72, 0x65, 0x73, 0x6f, 0x6c, 0x76, 0x65, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73,
+	0x65, 0x73, 0x12, 0x48, 0x0a, 0x0d, 0x70, 0x6f, 0x6c, 0x6c, 0x5f, 0x69, 0x6e, 0x74, 0x65, 0x72,
+	0x76, 0x61, 0x6c, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
+	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44, 0x75, 0x72, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x42, 0x08, 0xfa, 0x42, 0x05, 0xaa, 0x01, 0x02, 0x2a, 0x00, 0x52, 0x0c,
+	0x70, 0x6f, 0x6c, 0x6c, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x22, 0x42, 0x0a, 0x0d,
+	0x50, 0x72, 0x69, 0x6f, 0x72, 0x69, 0x74, 0x79, 0x54, 0x69, 0x65, 0x72, 0x73, 0x12, 0x16, 0x0a,
+	0x12, 0x50, 0x52, 0x49, 0x4f, 0x52, 0x49, 0x54, 0x59, 0x5f, 0x54, 0x49, 0x45, 0x52, 0x53, 0x5f,
+	0x41, 0x4c, 0x4c, 0x10, 0x00, 0x12, 0x19, 0x0a, 0x15, 0x50, 0x52, 0x49, 0x4f, 0x52, 0x49, 0x54,
+	0x59, 0x5f, 0x54, 0x49, 0x45, 0x52, 0x53, 0x5f, 0x4c, 0x4f, 0x57, 0x45, 0x53, 0x54, 0x10, 0x01,
+	0x1a, 0xaa, 0x02, 0x0a, 0x11, 0x4b, 0x75, 0x62, 0x65, 0x72, 0x6e, 0x65, 0x74, 0x65, 0x73, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x4b, 0x0a, 0x09, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x70,
+	0x61, 0x63, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x42, 0x2d, 0xfa, 0x42, 0x2a, 0x72, 0x28,
+	0x28, 0x3f, 0x32, 0x24, 0x5e, 0x5b, 0x61, 0x2d, 0x7a, 0x30, 0x2d, 0x39, 0x5d, 0x28, 0x5b, 0x61,
+	0x2d, 0x7a, 0x30, 0x2d, 0x39, 0x2d, 0x5d, 0x7b, 0x30, 0x2c, 0x36, 0x31, 0x7d, 0x5b, 0x61, 0x2d,
+	0x7a, 0x30, 0x2d, 0x39, 0x5d, 0x29, 0x3f, 0x24, 0x52, 0x09, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x70,
+	0x61, 0x63, 0x65, 0x12, 0x50, 0x0a, 0x0c, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x5f, 0x6e,
+	0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x42, 0x2d, 0xfa, 0x42, 0x2a, 0x72, 0x28,
+	0x28, 0x3f, 0x32, 0x24, 0x5e, 0x5b, 0x61, 0x2d, 0x7a, 0x30, 0x2d, 0x39, 0x5d, 0x28, 0x5b, 0x61,
+	0x2d, 0x7a, 0x30, 0x2d, 0x39, 0x2d, 0x5d, 0x7b, 0x30, 0x2c, 0x36, 0x31, 0x7d, 0x5b, 0x61, 0x2d,
+	0x7a, 0x30, 0x2d, 0x39, 0x5d, 0x29, 0x3f, 0x24, 0x52, 0x0b, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63,
+	0x65, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x4d, 0x0a, 0x09, 0x70, 0x6f, 0x72, 0x74, 0x5f, 0x6e, 0x61,
+	0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x42, 0x30, 0xfa, 0x42, 0x2d, 0x72, 0x2b, 0x28,
+	0x0f, 0x32, 0x24, 0x5e, 0x5b, 0x61, 0x2d, 0x7a, 0x30, 0x2d, 0x39, 0x5d, 0x28, 0x5b, 0x61, 0x2d,
+	0x7a, 0x30, 0x2d, 0x39, 0x2d, 0x5d, 0x7b, 0x30, 0x2c, 0x31, 0x33, 0x7d, 0x5b, 0x61, 0x2d, 0x7a,
+	0x30, 0x2d, 0x39, 0x5d, 0x29, 0x3f, 0x24, 0xd0, 0x01, 0x01, 0x52, 0x08, 0x70, 0x6f, 0x72, 0x74,
+	0x4e, 0x61, 0x6d, 0x65, 0x12, 0x27, 0x0a, 0x0f, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x5f,
+	0x75, 0x6e, 0x72, 0x65, 0x61, 0x64, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0e, 0x69,
+	0x6e, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x55, 0x6e, 0x72, 0x65, 0x61, 0x64, 0x79, 0x1a, 0x4b, 0x0a,
+	0x0b, 0x46, 0x69, 0x6c, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x3c, 0x0a, 0x04,
+	0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x42, 0x28, 0xfa, 0x42, 0x25, 0x72,
+	0x23, 0x28, 0xff, 0x01, 0x32, 0x1e, 0x5e, 0x5b, 0x61, 0x2d, 0x7a, 0x41, 0x2d, 0x5a, 0x30, 0x2d,
+	0x39, 0x5f, 0x2d, 0x5d, 0x5b, 0x61, 0x2d, 0x7a, 0x41, 0x2d, 0x5a, 0x30, 0x2d, 0x39, 0x5f, 0x2e,
+	0x2d, 0x5d, 0x2a, 0x24, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x42, 0x19, 0x0a, 0x12, 0x72, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x5f, 0x6f, 0x6e, 0x65, 0x6f, 0x66,
+	0x12, 0x03, 0xf8, 0x42, 0x01, 0x22, 0xc7, 0x05, 0x0a, 0x0d, 0x57, 0x61, 0x74, 0x63, 0x68, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x35, 0x0a, 0x09, 0x65, 0x6e, 0x64, 0x70, 0x6f,
+	0x69, 0x6e, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x73, 0x65, 0x72,
+	0x76, 0x6f, 0x6b, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x6e, 0x64, 0x70, 0x6f,
+	0x69, 0x6e, 0x74, 0x52, 0x09, 0x65, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x12, 0x1a,
+	0x0a, 0x08, 0x72, 0x65, 0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04,
+	0x52, 0x08, 0x72, 0x65, 0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x4e, 0x0a, 0x0d, 0x72, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x0e, 0x32, 0x29, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x6f, 0x6b, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76,
+	0x31, 0x2e, 0x57, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x54, 0x79, 0x70, 0x65, 0x52, 0x0c, 0x72, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x54, 0x79, 0x70, 0x65, 0x12, 0x2d, 0x0a, 0x05, 0x61, 0x64,
+	0x64, 0x65, 0x64, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x73, 0x65, 0x72, 0x76,
+	0x6f, 0x6b, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x6e, 0x64, 0x70, 0x6f, 0x69,
+	0x6e, 0x74, 0x52, 0x05, 0x61, 0x64, 0x64, 0x65, 0x64, 0x12, 0x31, 0x0a, 0x07, 0x72, 0x65, 0x6d,
+	0x6f, 0x76, 0x65, 0x64, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x73, 0x65, 0x72,
+	0x76, 0x6f, 0x6b, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x6e, 0x64, 0x70, 0x6f,
+	0x69, 0x6e, 0x74, 0x52, 0x07, 0x72, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x64, 0x12, 0x33, 0x0a, 0x08,
+	0x6d, 0x6f, 0x64, 0x69, 0x66, 0x69, 0x65, 0x64, 0x18, 0x06, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17,
+	0x2e, 0x73, 0x65, 0x72, 0x76, 0x6f, 0x6b, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x45,
+	0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x52, 0x08, 0x6d, 0x6f, 0x64, 0x69, 0x66, 0x69, 0x65,
+	0x64, 0x12, 0x4e, 0x0a, 0x0d, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x5f, 0x73, 0x74, 0x61, 0x74,
+	0x75, 0x73, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x29, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x6f,
 	0x6b, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x57, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65,
-	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x6f, 0x6b, 0x2e, 0x61,
-	0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x57, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x73, 0x70, 0x6f,
-	0x6e, 0x73, 0x65, 0x22, 0x00, 0x30, 0x01, 0x42, 0xa8, 0x01, 0x0a, 0x11, 0x63, 0x6f, 0x6d, 0x2e,
-	0x73, 0x65, 0x72, 0x76, 0x6f, 0x6b, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x42, 0x07, 0x56,
-	0x31, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x50, 0x01, 0x5a, 0x34, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62,
-	0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x61, 0x75, 0x74, 0x68, 0x7a, 0x65, 0x64, 0x2f, 0x73, 0x65, 0x72,
-	0x76, 0x6f, 0x6b, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x76, 0x31, 0x2f, 0x73, 0x65, 0x72, 0x76, 0x6f,
-	0x6b, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x76, 0x31, 0x3b, 0x61, 0x70, 0x69, 0x76, 0x31, 0xa2, 0x02,
-	0x03, 0x53, 0x41, 0x58, 0xaa, 0x02, 0x0d, 0x53, 0x65, 0x72, 0x76, 0x6f, 0x6b, 0x2e, 0x41, 0x70,
-	0x69, 0x2e, 0x56, 0x31, 0xca, 0x02, 0x0d, 0x53, 0x65, 0x72, 0x76, 0x6f, 0x6b, 0x5c, 0x41, 0x70,
-	0x69, 0x5c, 0x56, 0x31, 0xe2, 0x02, 0x19, 0x53, 0x65, 0x72, 0x76, 0x6f, 0x6b, 0x5c, 0x41, 0x70,
-	0x69, 0x5c, 0x56, 0x31, 0x5c, 0x47, 0x50, 0x42, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61,
-	0xea, 0x02, 0x0f, 0x53, 0x65, 0x72, 0x76, 0x6f, 0x6b, 0x3a, 0x3a, 0x41, 0x70, 0x69, 0x3a, 0x3a,
-	0x56, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x53, 0x74, 0x61,
+	0x74, 0x75, 0x73, 0x52, 0x0c, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x53, 0x74, 0x61, 0x74, 0x75,
+	0x73, 0x12, 0x50, 0x0a, 0x16, 0x6c, 0x61, 0x73, 0x74, 0x5f, 0x73, 0x75, 0x63, 0x63, 0x65, 0x73,
+	0x73, 0x66, 0x75, 0x6c, 0x5f, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x14, 0x6c,
+	0x61, 0x73, 0x74, 0x53, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x66, 0x75, 0x6c, 0x55, 0x70, 0x64,
+	0x61, 0x74, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x5f, 0x65, 0x72,
+	0x72, 0x6f, 0x72, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x73, 0x6f, 0x75, 0x72, 0x63,
+	0x65, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x43, 0x0a, 0x0c, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x54, 0x79, 0x70, 0x65, 0x12, 0x1a, 0x0a, 0x16, 0x52, 0x45, 0x53, 0x50, 0x4f, 0x4e,
+	0x53, 0x45, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x53, 0x4e, 0x41, 0x50, 0x53, 0x48, 0x4f, 0x54,
+	0x10, 0x00, 0x12, 0x17, 0x0a, 0x13, 0x52, 0x45, 0x53, 0x50, 0x4f, 0x4e, 0x53, 0x45, 0x5f, 0x54,
+	0x59, 0x50, 0x45, 0x5f, 0x44, 0x45, 0x4c, 0x54, 0x41, 0x10, 0x01, 0x22, 0x72, 0x0a, 0x0c, 0x53,
+	0x6f, 0x75, 0x72, 0x63, 0x65, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x14, 0x0a, 0x10, 0x53,
+	0x4f, 0x55, 0x52, 0x43, 0x45, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x4f, 0x4b, 0x10,
+	0x00, 0x12, 0x1a, 0x0a, 0x16, 0x53, 0x4f, 0x55, 0x52, 0x43, 0x45, 0x5f, 0x53, 0x54, 0x41, 0x54,
+	0x55, 0x53, 0x5f, 0x44, 0x45, 0x47, 0x52, 0x41, 0x44, 0x45, 0x44, 0x10, 0x01, 0x12, 0x17, 0x0a,
+	0x13, 0x53, 0x4f, 0x55, 0x52, 0x43, 0x45, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x53,
+	0x54, 0x41, 0x4c, 0x45, 0x10, 0x02, 0x12, 0x17, 0x0a, 0x13, 0x53, 0x4f, 0x55, 0x52, 0x43, 0x45,
+	0x5f, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x10, 0x03, 0x22,
+	0x55, 0x0a, 0x10, 0x57, 0x61, 0x74, 0x63, 0x68, 0x4d, 0x61, 0x6e, 0x79, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x41, 0x0a, 0x07, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x73, 0x18, 0x01,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x6f, 0x6b, 0x2e, 0x61, 0x70,
+	0x69, 0x2e, 0x76, 0x31, 0x2e, 0x57, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x42, 0x0a, 0xfa, 0x42, 0x07, 0x92, 0x01, 0x04, 0x08, 0x01, 0x10, 0x64, 0x52, 0x07, 0x74,
+	0x61, 0x72, 0x67, 0x65, 0x74, 0x73, 0x22, 0x70, 0x0a, 0x11, 0x57, 0x61, 0x74, 0x63, 0x68, 0x4d,
+	0x61, 0x6e, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x74,
+	0x61, 0x72, 0x67, 0x65, 0x74, 0x5f, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0d, 0x52, 0x0b, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x38,
+	0x0a, 0x08, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x1c, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x6f, 0x6b, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31,
+	0x2e, 0x57, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x52, 0x08,
+	0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x54, 0x0a, 0x13, 0x47, 0x65, 0x74, 0x45,
+	0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x3d, 0x0a, 0x06, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x1b, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x6f, 0x6b, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e,
+	0x57, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x42, 0x08, 0xfa, 0x42,
+	0x05, 0x8a, 0x01, 0x02, 0x10, 0x01, 0x52, 0x06, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x22, 0xae,
+	0x02, 0x0a, 0x14, 0x47, 0x65, 0x74, 0x45, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x35, 0x0a, 0x09, 0x65, 0x6e, 0x64, 0x70, 0x6f,
+	0x69, 0x6e, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x73, 0x65, 0x72,
+	0x76, 0x6f, 0x6b, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x6e, 0x64, 0x70, 0x6f,
+	0x69, 0x6e, 0x74, 0x52, 0x09, 0x65, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x12, 0x1a,
+	0x0a, 0x08, 0x72, 0x65, 0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04,
+	0x52, 0x08, 0x72, 0x65, 0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x4e, 0x0a, 0x0d, 0x73, 0x6f,
+	0x75, 0x72, 0x63, 0x65, 0x5f, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x0e, 0x32, 0x29, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x6f, 0x6b, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76,
+	0x31, 0x2e, 0x57, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e,
+	0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x0c, 0x73, 0x6f,
+	0x75, 0x72, 0x63, 0x65, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x50, 0x0a, 0x16, 0x6c, 0x61,
+	0x73, 0x74, 0x5f, 0x73, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x66, 0x75, 0x6c, 0x5f, 0x75, 0x70,
+	0x64, 0x61, 0x74, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d,
+	0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x14, 0x6c, 0x61, 0x73, 0x74, 0x53, 0x75, 0x63, 0x63,
+	0x65, 0x73, 0x73, 0x66, 0x75, 0x6c, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x12, 0x21, 0x0a, 0x0c,
+	0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x5f, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0b, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x22,
+	0x14, 0x0a, 0x12, 0x4c, 0x69, 0x73, 0x74, 0x57, 0x61, 0x74, 0x63, 0x68, 0x65, 0x73, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0xc8, 0x01, 0x0a, 0x13, 0x4c, 0x69, 0x73, 0x74, 0x57, 0x61,
+	0x74, 0x63, 0x68, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x42, 0x0a,
+	0x07, 0x77, 0x61, 0x74, 0x63, 0x68, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x28,
+	0x2e, 0x73, 0x65, 0x72, 0x76, 0x6f, 0x6b, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x4c,
+	0x69, 0x73, 0x74, 0x57, 0x61, 0x74, 0x63, 0x68, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x2e, 0x57, 0x61, 0x74, 0x63, 0x68, 0x52, 0x07, 0x77, 0x61, 0x74, 0x63, 0x68, 0x65,
+	0x73, 0x1a, 0x6d, 0x0a, 0x05, 0x57, 0x61, 0x74, 0x63, 0x68, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65,
+	0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x18, 0x0a, 0x07,
+	0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x63,
+	0x6c, 0x69, 0x65, 0x6e, 0x74, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x72, 0x65, 0x76, 0x69, 0x73, 0x69,
+	0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x72, 0x65, 0x76, 0x69, 0x73, 0x69,
+	0x6f, 0x6e, 0x12, 0x1c, 0x0a, 0x09, 0x65, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x09, 0x65, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x73,
+	0x22, 0xb0, 0x02, 0x0a, 0x08, 0x45, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x12, 0x1a, 0x0a,
+	0x08, 0x68, 0x6f, 0x73, 0x74, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x08, 0x68, 0x6f, 0x73, 0x74, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x6f, 0x72,
+	0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x04, 0x70, 0x6f, 0x72, 0x74, 0x12, 0x16, 0x0a,
+	0x06, 0x77, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x77,
+	0x65, 0x69, 0x67, 0x68, 0x74, 0x12, 0x42, 0x0a, 0x0a, 0x63, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69,
+	0x6f, 0x6e, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x22, 0x2e, 0x73, 0x65, 0x72, 0x76,
+	0x6f, 0x6b, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x6e, 0x64, 0x70, 0x6f, 0x69,
+	0x6e, 0x74, 0x2e, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x0a, 0x63,
+	0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x72, 0x69,
+	0x6f, 0x72, 0x69, 0x74, 0x79, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x70, 0x72, 0x69,
+	0x6f, 0x72, 0x69, 0x74, 0x79, 0x12, 0x1c, 0x0a, 0x09, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73,
+	0x65, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x09, 0x52, 0x09, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73,
+	0x73, 0x65, 0x73, 0x1a, 0x5e, 0x0a, 0x0a, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e,
+	0x73, 0x12, 0x14, 0x0a, 0x05, 0x72, 0x65, 0x61, 0x64, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x05, 0x72, 0x65, 0x61, 0x64, 0x79, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x65, 0x72, 0x76, 0x69,
+	0x6e, 0x67, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x73, 0x65, 0x72, 0x76, 0x69, 0x6e,
+	0x67, 0x12, 0x20, 0x0a, 0x0b, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6e, 0x67,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0b, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x74,
+	0x69, 0x6e, 0x67, 0x32, 0xe0, 0x02, 0x0a, 0x0f, 0x45, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74,
+	0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x46, 0x0a, 0x05, 0x57, 0x61, 0x74, 0x63, 0x68,
+	0x12, 0x1b, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x6f, 0x6b, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31,
+	0x2e, 0x57, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e,
+	0x73, 0x65, 0x72, 0x76, 0x6f, 0x6b, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x57, 0x61,
+	0x74, 0x63, 0x68, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x30, 0x01, 0x12,
+	0x52, 0x0a, 0x09, 0x57, 0x61, 0x74, 0x63, 0x68, 0x4d, 0x61, 0x6e, 0x79, 0x12, 0x1f, 0x2e, 0x73,
+	0x65, 0x72, 0x76, 0x6f, 0x6b, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x57, 0x61, 0x74,
+	0x63, 0x68, 0x4d, 0x61, 0x6e, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x20, 0x2e,
+	0x73, 0x65, 0x72, 0x76, 0x6f, 0x6b, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x57, 0x61,
+	0x74, 0x63, 0x68, 0x4d, 0x61, 0x6e, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22,
+	0x00, 0x30, 0x01, 0x12, 0x59, 0x0a, 0x0c, 0x47, 0x65, 0x74, 0x45, 0x6e, 0x64, 0x70, 0x6f, 0x69,
+	0x6e, 0x74, 0x73, 0x12, 0x22, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x6f, 0x6b, 0x2e, 0x61, 0x70, 0x69,
+	0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x45, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x73,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x23, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x6f, 0x6b,
+	0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x45, 0x6e, 0x64, 0x70, 0x6f,
+	0x69, 0x6e, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x56,
+	0x0a, 0x0b, 0x4c, 0x69, 0x73, 0x74, 0x57, 0x61, 0x74, 0x63, 0x68, 0x65, 0x73, 0x12, 0x21, 0x2e,
+	0x73, 0x65, 0x72, 0x76, 0x6f, 0x6b, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69,
+	0x73, 0x74, 0x57, 0x61, 0x74, 0x63, 0x68, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x22, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x6f, 0x6b, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31,
+	0x2e, 0x4c, 0x69, 0x73, 0x74, 0x57, 0x61, 0x74, 0x63, 0x68, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x42, 0xa8, 0x01, 0x0a, 0x11, 0x63, 0x6f, 0x6d, 0x2e, 0x73,
+	0x65, 0x72, 0x76, 0x6f, 0x6b, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x42, 0x07, 0x56, 0x31,
+	0x50, 0x72, 0x6f, 0x74, 0x6f, 0x50, 0x01, 0x5a, 0x34, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e,
+	0x63, 0x6f, 0x6d, 0x2f, 0x61, 0x75, 0x74, 0x68, 0x7a, 0x65, 0x64, 0x2f, 0x73, 0x65, 0x72, 0x76,
+	0x6f, 0x6b, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x76, 0x31, 0x2f, 0x73, 0x65, 0x72, 0x76, 0x6f, 0x6b,
+	0x2f, 0x61, 0x70, 0x69, 0x2f, 0x76, 0x31, 0x3b, 0x61, 0x70, 0x69, 0x76, 0x31, 0xa2, 0x02, 0x03,
+	0x53, 0x41, 0x58, 0xaa, 0x02, 0x0d, 0x53, 0x65, 0x72, 0x76, 0x6f, 0x6b, 0x2e, 0x41, 0x70, 0x69,
+	0x2e, 0x56, 0x31, 0xca, 0x02, 0x0d, 0x53, 0x65, 0x72, 0x76, 0x6f, 0x6b, 0x5c, 0x41, 0x70, 0x69,
+	0x5c, 0x56, 0x31, 0xe2, 0x02, 0x19, 0x53, 0x65, 0x72, 0x76, 0x6f, 0x6b, 0x5c, 0x41, 0x70, 0x69,
+	0x5c, 0x56, 0x31, 0x5c, 0x47, 0x50, 0x42, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0xea,
+	0x02, 0x0f, 0x53, 0x65, 0x72, 0x76, 0x6f, 0x6b, 0x3a, 0x3a, 0x41, 0x70, 0x69, 0x3a, 0x3a, 0x56,
+	0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -329,23 +1440,63 @@ func file_servok_api_v1_v1_proto_rawDescGZIP() []byte {
 	return file_servok_api_v1_v1_proto_rawDescData
 }
 
-var file_servok_api_v1_v1_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_servok_api_v1_v1_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_servok_api_v1_v1_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
 var file_servok_api_v1_v1_proto_goTypes = []interface{}{
-	(*WatchRequest)(nil),            // 0: servok.api.v1.WatchRequest
-	(*WatchResponse)(nil),           // 1: servok.api.v1.WatchResponse
-	(*Endpoint)(nil),                // 2: servok.api.v1.Endpoint
-	(*WatchRequest_SRVRequest)(nil), // 3: servok.api.v1.WatchRequest.SRVRequest
+	(WatchRequest_SRVRequest_PriorityTiers)(0), // 0: servok.api.v1.WatchRequest.SRVRequest.PriorityTiers
+	(WatchResponse_ResponseType)(0),            // 1: servok.api.v1.WatchResponse.ResponseType
+	(WatchResponse_SourceStatus)(0),            // 2: servok.api.v1.WatchResponse.SourceStatus
+	(*WatchRequest)(nil),                       // 3: servok.api.v1.WatchRequest
+	(*WatchResponse)(nil),                      // 4: servok.api.v1.WatchResponse
+	(*WatchManyRequest)(nil),                   // 5: servok.api.v1.WatchManyRequest
+	(*WatchManyResponse)(nil),                  // 6: servok.api.v1.WatchManyResponse
+	(*GetEndpointsRequest)(nil),                // 7: servok.api.v1.GetEndpointsRequest
+	(*GetEndpointsResponse)(nil),               // 8: servok.api.v1.GetEndpointsResponse
+	(*ListWatchesRequest)(nil),                 // 9: servok.api.v1.ListWatchesRequest
+	(*ListWatchesResponse)(nil),                // 10: servok.api.v1.ListWatchesResponse
+	(*Endpoint)(nil),                           // 11: servok.api.v1.Endpoint
+	(*WatchRequest_SRVRequest)(nil),            // 12: servok.api.v1.WatchRequest.SRVRequest
+	(*WatchRequest_KubernetesRequest)(nil),     // 13: servok.api.v1.WatchRequest.KubernetesRequest
+	(*WatchRequest_FileRequest)(nil),           // 14: servok.api.v1.WatchRequest.FileRequest
+	(*ListWatchesResponse_Watch)(nil),          // 15: servok.api.v1.ListWatchesResponse.Watch
+	(*Endpoint_Conditions)(nil),                // 16: servok.api.v1.Endpoint.Conditions
+	(*timestamppb.Timestamp)(nil),              // 17: google.protobuf.Timestamp
+	(*durationpb.Duration)(nil),                // 18: google.protobuf.Duration
 }
 var file_servok_api_v1_v1_proto_depIdxs = []int32{
-	3, // 0: servok.api.v1.WatchRequest.srv:type_name -> servok.api.v1.WatchRequest.SRVRequest
-	2, // 1: servok.api.v1.WatchResponse.endpoints:type_name -> servok.api.v1.Endpoint
-	0, // 2: servok.api.v1.EndpointService.Watch:input_type -> servok.api.v1.WatchRequest
-	1, // 3: servok.api.v1.EndpointService.Watch:output_type -> servok.api.v1.WatchResponse
-	3, // [3:4] is the sub-list for method output_type
-	2, // [2:3] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	12, // 0: servok.api.v1.WatchRequest.srv:type_name -> servok.api.v1.WatchRequest.SRVRequest
+	13, // 1: servok.api.v1.WatchRequest.kubernetes:type_name -> servok.api.v1.WatchRequest.KubernetesRequest
+	14, // 2: servok.api.v1.WatchRequest.file:type_name -> servok.api.v1.WatchRequest.FileRequest
+	11, // 3: servok.api.v1.WatchResponse.endpoints:type_name -> servok.api.v1.Endpoint
+	1,  // 4: servok.api.v1.WatchResponse.response_type:type_name -> servok.api.v1.WatchResponse.ResponseType
+	11, // 5: servok.api.v1.WatchResponse.added:type_name -> servok.api.v1.Endpoint
+	11, // 6: servok.api.v1.WatchResponse.removed:type_name -> servok.api.v1.Endpoint
+	11, // 7: servok.api.v1.WatchResponse.modified:type_name -> servok.api.v1.Endpoint
+	2,  // 8: servok.api.v1.WatchResponse.source_status:type_name -> servok.api.v1.WatchResponse.SourceStatus
+	17, // 9: servok.api.v1.WatchResponse.last_successful_update:type_name -> google.protobuf.Timestamp
+	3,  // 10: servok.api.v1.WatchManyRequest.targets:type_name -> servok.api.v1.WatchRequest
+	4,  // 11: servok.api.v1.WatchManyResponse.response:type_name -> servok.api.v1.WatchResponse
+	3,  // 12: servok.api.v1.GetEndpointsRequest.target:type_name -> servok.api.v1.WatchRequest
+	11, // 13: servok.api.v1.GetEndpointsResponse.endpoints:type_name -> servok.api.v1.Endpoint
+	2,  // 14: servok.api.v1.GetEndpointsResponse.source_status:type_name -> servok.api.v1.WatchResponse.SourceStatus
+	17, // 15: servok.api.v1.GetEndpointsResponse.last_successful_update:type_name -> google.protobuf.Timestamp
+	15, // 16: servok.api.v1.ListWatchesResponse.watches:type_name -> servok.api.v1.ListWatchesResponse.Watch
+	16, // 17: servok.api.v1.Endpoint.conditions:type_name -> servok.api.v1.Endpoint.Conditions
+	0,  // 18: servok.api.v1.WatchRequest.SRVRequest.priority_tiers:type_name -> servok.api.v1.WatchRequest.SRVRequest.PriorityTiers
+	18, // 19: servok.api.v1.WatchRequest.SRVRequest.poll_interval:type_name -> google.protobuf.Duration
+	3,  // 20: servok.api.v1.EndpointService.Watch:input_type -> servok.api.v1.WatchRequest
+	5,  // 21: servok.api.v1.EndpointService.WatchMany:input_type -> servok.api.v1.WatchManyRequest
+	7,  // 22: servok.api.v1.EndpointService.GetEndpoints:input_type -> servok.api.v1.GetEndpointsRequest
+	9,  // 23: servok.api.v1.EndpointService.ListWatches:input_type -> servok.api.v1.ListWatchesRequest
+	4,  // 24: servok.api.v1.EndpointService.Watch:output_type -> servok.api.v1.WatchResponse
+	6,  // 25: servok.api.v1.EndpointService.WatchMany:output_type -> servok.api.v1.WatchManyResponse
+	8,  // 26: servok.api.v1.EndpointService.GetEndpoints:output_type -> servok.api.v1.GetEndpointsResponse
+	10, // 27: servok.api.v1.EndpointService.ListWatches:output_type -> servok.api.v1.ListWatchesResponse
+	24, // [24:28] is the sub-list for method output_type
+	20, // [20:24] is the sub-list for method input_type
+	20, // [20:20] is the sub-list for extension type_name
+	20, // [20:20] is the sub-list for extension extendee
+	0,  // [0:20] is the sub-list for field type_name
 }
 
 func init() { file_servok_api_v1_v1_proto_init() }
@@ -379,7 +1530,7 @@ func file_servok_api_v1_v1_proto_init() {
 			}
 		}
 		file_servok_api_v1_v1_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Endpoint); i {
+			switch v := v.(*WatchManyRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -391,6 +1542,78 @@ func file_servok_api_v1_v1_proto_init() {
 			}
 		}
 		file_servok_api_v1_v1_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*WatchManyResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_servok_api_v1_v1_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetEndpointsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_servok_api_v1_v1_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetEndpointsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_servok_api_v1_v1_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListWatchesRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_servok_api_v1_v1_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListWatchesResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_servok_api_v1_v1_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Endpoint); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_servok_api_v1_v1_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*WatchRequest_SRVRequest); i {
 			case 0:
 				return &v.state
@@ -402,22 +1625,73 @@ func file_servok_api_v1_v1_proto_init() {
 				return nil
 			}
 		}
+		file_servok_api_v1_v1_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*WatchRequest_KubernetesRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_servok_api_v1_v1_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*WatchRequest_FileRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_servok_api_v1_v1_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListWatchesResponse_Watch); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_servok_api_v1_v1_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Endpoint_Conditions); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	file_servok_api_v1_v1_proto_msgTypes[0].OneofWrappers = []interface{}{
 		(*WatchRequest_Srv)(nil),
+		(*WatchRequest_Kubernetes)(nil),
+		(*WatchRequest_File)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_servok_api_v1_v1_proto_rawDesc,
-			NumEnums:      0,
-			NumMessages:   4,
+			NumEnums:      3,
+			NumMessages:   14,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_servok_api_v1_v1_proto_goTypes,
 		DependencyIndexes: file_servok_api_v1_v1_proto_depIdxs,
+		EnumInfos:         file_servok_api_v1_v1_proto_enumTypes,
 		MessageInfos:      file_servok_api_v1_v1_proto_msgTypes,
 	}.Build()
 	File_servok_api_v1_v1_proto = out.File
@@ -41,6 +41,10 @@ func (m *WatchRequest) Validate() error {
 		return nil
 	}
 
+	// no validation rules for Delta
+
+	// no validation rules for LastSeenRevision
+
 	switch m.RequestTypeOneof.(type) {
 
 	case *WatchRequest_Srv:
@@ -62,6 +66,44 @@ func (m *WatchRequest) Validate() error {
 			}
 		}
 
+	case *WatchRequest_Kubernetes:
+
+		if m.GetKubernetes() == nil {
+			return WatchRequestValidationError{
+				field:  "Kubernetes",
+				reason: "value is required",
+			}
+		}
+
+		if v, ok := interface{}(m.GetKubernetes()).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return WatchRequestValidationError{
+					field:  "Kubernetes",
+					reason: "embedded message failed validation",
+					cause:  err,
+				}
+			}
+		}
+
+	case *WatchRequest_File:
+
+		if m.GetFile() == nil {
+			return WatchRequestValidationError{
+				field:  "File",
+				reason: "value is required",
+			}
+		}
+
+		if v, ok := interface{}(m.GetFile()).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return WatchRequestValidationError{
+					field:  "File",
+					reason: "embedded message failed validation",
+					cause:  err,
+				}
+			}
+		}
+
 	default:
 		return WatchRequestValidationError{
 			field:  "RequestTypeOneof",
@@ -150,6 +192,69 @@ func (m *WatchResponse) Validate() error {
 
 	}
 
+	// no validation rules for Revision
+
+	// no validation rules for ResponseType
+
+	for idx, item := range m.GetAdded() {
+		_, _ = idx, item
+
+		if v, ok := interface{}(item).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return WatchResponseValidationError{
+					field:  fmt.Sprintf("Added[%v]", idx),
+					reason: "embedded message failed validation",
+					cause:  err,
+				}
+			}
+		}
+
+	}
+
+	for idx, item := range m.GetRemoved() {
+		_, _ = idx, item
+
+		if v, ok := interface{}(item).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return WatchResponseValidationError{
+					field:  fmt.Sprintf("Removed[%v]", idx),
+					reason: "embedded message failed validation",
+					cause:  err,
+				}
+			}
+		}
+
+	}
+
+	for idx, item := range m.GetModified() {
+		_, _ = idx, item
+
+		if v, ok := interface{}(item).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return WatchResponseValidationError{
+					field:  fmt.Sprintf("Modified[%v]", idx),
+					reason: "embedded message failed validation",
+					cause:  err,
+				}
+			}
+		}
+
+	}
+
+	// no validation rules for SourceStatus
+
+	if v, ok := interface{}(m.GetLastSuccessfulUpdate()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return WatchResponseValidationError{
+				field:  "LastSuccessfulUpdate",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	// no validation rules for SourceError
+
 	return nil
 }
 
@@ -207,25 +312,42 @@ var _ interface {
 	ErrorName() string
 } = WatchResponseValidationError{}
 
-// Validate checks the field values on Endpoint with the rules defined in the
-// proto definition for this message. If any rules are violated, an error is returned.
-func (m *Endpoint) Validate() error {
+// Validate checks the field values on WatchManyRequest with the rules defined
+// in the proto definition for this message. If any rules are violated, an
+// error is returned.
+func (m *WatchManyRequest) Validate() error {
 	if m == nil {
 		return nil
 	}
 
-	// no validation rules for Hostname
+	if l := len(m.GetTargets()); l < 1 || l > 100 {
+		return WatchManyRequestValidationError{
+			field:  "Targets",
+			reason: "value must contain between 1 and 100 items, inclusive",
+		}
+	}
 
-	// no validation rules for Port
+	for idx, item := range m.GetTargets() {
+		_, _ = idx, item
 
-	// no validation rules for Weight
+		if v, ok := interface{}(item).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return WatchManyRequestValidationError{
+					field:  fmt.Sprintf("Targets[%v]", idx),
+					reason: "embedded message failed validation",
+					cause:  err,
+				}
+			}
+		}
+
+	}
 
 	return nil
 }
 
-// EndpointValidationError is the validation error returned by
-// Endpoint.Validate if the designated constraints aren't met.
-type EndpointValidationError struct {
+// WatchManyRequestValidationError is the validation error returned by
+// WatchManyRequest.Validate if the designated constraints aren't met.
+type WatchManyRequestValidationError struct {
 	field  string
 	reason string
 	cause  error
@@ -233,22 +355,22 @@ type EndpointValidationError struct {
 }
 
 // Field function returns field value.
-func (e EndpointValidationError) Field() string { return e.field }
+func (e WatchManyRequestValidationError) Field() string { return e.field }
 
 // Reason function returns reason value.
-func (e EndpointValidationError) Reason() string { return e.reason }
+func (e WatchManyRequestValidationError) Reason() string { return e.reason }
 
 // Cause function returns cause value.
-func (e EndpointValidationError) Cause() error { return e.cause }
+func (e WatchManyRequestValidationError) Cause() error { return e.cause }
 
 // Key function returns key value.
-func (e EndpointValidationError) Key() bool { return e.key }
+func (e WatchManyRequestValidationError) Key() bool { return e.key }
 
 // ErrorName returns error name.
-func (e EndpointValidationError) ErrorName() string { return "EndpointValidationError" }
+func (e WatchManyRequestValidationError) ErrorName() string { return "WatchManyRequestValidationError" }
 
 // Error satisfies the builtin error interface
-func (e EndpointValidationError) Error() string {
+func (e WatchManyRequestValidationError) Error() string {
 	cause := ""
 	if e.cause != nil {
 		cause = fmt.Sprintf(" | caused by: %v", e.cause)
@@ -260,14 +382,14 @@ func (e EndpointValidationError) Error() string {
 	}
 
 	return fmt.Sprintf(
-		"invalid %sEndpoint.%s: %s%s",
+		"invalid %sWatchManyRequest.%s: %s%s",
 		key,
 		e.field,
 		e.reason,
 		cause)
 }
 
-var _ error = EndpointValidationError{}
+var _ error = WatchManyRequestValidationError{}
 
 var _ interface {
 	Field() string
@@ -275,57 +397,118 @@ var _ interface {
 	Key() bool
 	Cause() error
 	ErrorName() string
-} = EndpointValidationError{}
+} = WatchManyRequestValidationError{}
 
-// Validate checks the field values on WatchRequest_SRVRequest with the rules
-// defined in the proto definition for this message. If any rules are
-// violated, an error is returned.
-func (m *WatchRequest_SRVRequest) Validate() error {
+// Validate checks the field values on WatchManyResponse with the rules defined
+// in the proto definition for this message. If any rules are violated, an
+// error is returned.
+func (m *WatchManyResponse) Validate() error {
 	if m == nil {
 		return nil
 	}
 
-	if len(m.GetService()) > 253 {
-		return WatchRequest_SRVRequestValidationError{
-			field:  "Service",
-			reason: "value length must be at most 253 bytes",
+	// no validation rules for TargetIndex
+
+	if v, ok := interface{}(m.GetResponse()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return WatchManyResponseValidationError{
+				field:  "Response",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
 		}
 	}
 
-	if !_WatchRequest_SRVRequest_Service_Pattern.MatchString(m.GetService()) {
-		return WatchRequest_SRVRequestValidationError{
-			field:  "Service",
-			reason: "value does not match regex pattern \"^[a-z0-9]([a-z0-9-\\\\.]{0,251}[a-z0-9])?$\"",
-		}
+	return nil
+}
+
+// WatchManyResponseValidationError is the validation error returned by
+// WatchManyResponse.Validate if the designated constraints aren't met.
+type WatchManyResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e WatchManyResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e WatchManyResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e WatchManyResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e WatchManyResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e WatchManyResponseValidationError) ErrorName() string {
+	return "WatchManyResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e WatchManyResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
 	}
 
-	if !_WatchRequest_SRVRequest_Protocol_Pattern.MatchString(m.GetProtocol()) {
-		return WatchRequest_SRVRequestValidationError{
-			field:  "Protocol",
-			reason: "value does not match regex pattern \"^((tcp)|(udp))$\"",
-		}
+	key := ""
+	if e.key {
+		key = "key for "
 	}
 
-	if len(m.GetDnsName()) > 253 {
-		return WatchRequest_SRVRequestValidationError{
-			field:  "DnsName",
-			reason: "value length must be at most 253 bytes",
+	return fmt.Sprintf(
+		"invalid %sWatchManyResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = WatchManyResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = WatchManyResponseValidationError{}
+
+// Validate checks the field values on GetEndpointsRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, an error is returned.
+func (m *GetEndpointsRequest) Validate() error {
+	if m == nil {
+		return nil
+	}
+
+	if m.GetTarget() == nil {
+		return GetEndpointsRequestValidationError{
+			field:  "Target",
+			reason: "value is required",
 		}
 	}
 
-	if !_WatchRequest_SRVRequest_DnsName_Pattern.MatchString(m.GetDnsName()) {
-		return WatchRequest_SRVRequestValidationError{
-			field:  "DnsName",
-			reason: "value does not match regex pattern \"^[a-z0-9]([a-z0-9-\\\\.]{0,251}[a-z0-9])?$\"",
+	if v, ok := interface{}(m.GetTarget()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return GetEndpointsRequestValidationError{
+				field:  "Target",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
 		}
 	}
 
 	return nil
 }
 
-// WatchRequest_SRVRequestValidationError is the validation error returned by
-// WatchRequest_SRVRequest.Validate if the designated constraints aren't met.
-type WatchRequest_SRVRequestValidationError struct {
+// GetEndpointsRequestValidationError is the validation error returned by
+// GetEndpointsRequest.Validate if the designated constraints aren't met.
+type GetEndpointsRequestValidationError struct {
 	field  string
 	reason string
 	cause  error
@@ -333,24 +516,24 @@ type WatchRequest_SRVRequestValidationError struct {
 }
 
 // Field function returns field value.
-func (e WatchRequest_SRVRequestValidationError) Field() string { return e.field }
+func (e GetEndpointsRequestValidationError) Field() string { return e.field }
 
 // Reason function returns reason value.
-func (e WatchRequest_SRVRequestValidationError) Reason() string { return e.reason }
+func (e GetEndpointsRequestValidationError) Reason() string { return e.reason }
 
 // Cause function returns cause value.
-func (e WatchRequest_SRVRequestValidationError) Cause() error { return e.cause }
+func (e GetEndpointsRequestValidationError) Cause() error { return e.cause }
 
 // Key function returns key value.
-func (e WatchRequest_SRVRequestValidationError) Key() bool { return e.key }
+func (e GetEndpointsRequestValidationError) Key() bool { return e.key }
 
 // ErrorName returns error name.
-func (e WatchRequest_SRVRequestValidationError) ErrorName() string {
-	return "WatchRequest_SRVRequestValidationError"
+func (e GetEndpointsRequestValidationError) ErrorName() string {
+	return "GetEndpointsRequestValidationError"
 }
 
 // Error satisfies the builtin error interface
-func (e WatchRequest_SRVRequestValidationError) Error() string {
+func (e GetEndpointsRequestValidationError) Error() string {
 	cause := ""
 	if e.cause != nil {
 		cause = fmt.Sprintf(" | caused by: %v", e.cause)
@@ -362,14 +545,14 @@ func (e WatchRequest_SRVRequestValidationError) Error() string {
 	}
 
 	return fmt.Sprintf(
-		"invalid %sWatchRequest_SRVRequest.%s: %s%s",
+		"invalid %sGetEndpointsRequest.%s: %s%s",
 		key,
 		e.field,
 		e.reason,
 		cause)
 }
 
-var _ error = WatchRequest_SRVRequestValidationError{}
+var _ error = GetEndpointsRequestValidationError{}
 
 var _ interface {
 	Field() string
@@ -377,10 +560,824 @@ var _ interface {
 	Key() bool
 	Cause() error
 	ErrorName() string
-} = WatchRequest_SRVRequestValidationError{}
+} = GetEndpointsRequestValidationError{}
 
-var _WatchRequest_SRVRequest_Service_Pattern = regexp.MustCompile("^[a-z0-9]([a-z0-9-\\.]{0,251}[a-z0-9])?$")
+// Validate checks the field values on GetEndpointsResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, an error is returned.
+func (m *GetEndpointsResponse) Validate() error {
+	if m == nil {
+		return nil
+	}
 
-var _WatchRequest_SRVRequest_Protocol_Pattern = regexp.MustCompile("^((tcp)|(udp))$")
+	for idx, item := range m.GetEndpoints() {
+		_, _ = idx, item
 
-var _WatchRequest_SRVRequest_DnsName_Pattern = regexp.MustCompile("^[a-z0-9]([a-z0-9-\\.]{0,251}[a-z0-9])?$")
+		if v, ok := interface{}(item).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return GetEndpointsResponseValidationError{
+					field:  fmt.Sprintf("Endpoints[%v]", idx),
+					reason: "embedded message failed validation",
+					cause:  err,
+				}
+			}
+		}
+
+	}
+
+	// no validation rules for Revision
+
+	// no validation rules for SourceStatus
+
+	if v, ok := interface{}(m.GetLastSuccessfulUpdate()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return GetEndpointsResponseValidationError{
+				field:  "LastSuccessfulUpdate",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	// no validation rules for SourceError
+
+	return nil
+}
+
+// GetEndpointsResponseValidationError is the validation error returned by
+// GetEndpointsResponse.Validate if the designated constraints aren't met.
+type GetEndpointsResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e GetEndpointsResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e GetEndpointsResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e GetEndpointsResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e GetEndpointsResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e GetEndpointsResponseValidationError) ErrorName() string {
+	return "GetEndpointsResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e GetEndpointsResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sGetEndpointsResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = GetEndpointsResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = GetEndpointsResponseValidationError{}
+
+// Validate checks the field values on ListWatchesRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, an error is returned.
+func (m *ListWatchesRequest) Validate() error {
+	if m == nil {
+		return nil
+	}
+
+	return nil
+}
+
+// ListWatchesRequestValidationError is the validation error returned by
+// ListWatchesRequest.Validate if the designated constraints aren't met.
+type ListWatchesRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e ListWatchesRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e ListWatchesRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e ListWatchesRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e ListWatchesRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e ListWatchesRequestValidationError) ErrorName() string {
+	return "ListWatchesRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e ListWatchesRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sListWatchesRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = ListWatchesRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = ListWatchesRequestValidationError{}
+
+// Validate checks the field values on ListWatchesResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, an error is returned.
+func (m *ListWatchesResponse) Validate() error {
+	if m == nil {
+		return nil
+	}
+
+	for idx, item := range m.GetWatches() {
+		_, _ = idx, item
+
+		if v, ok := interface{}(item).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return ListWatchesResponseValidationError{
+					field:  fmt.Sprintf("Watches[%v]", idx),
+					reason: "embedded message failed validation",
+					cause:  err,
+				}
+			}
+		}
+
+	}
+
+	return nil
+}
+
+// ListWatchesResponseValidationError is the validation error returned by
+// ListWatchesResponse.Validate if the designated constraints aren't met.
+type ListWatchesResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e ListWatchesResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e ListWatchesResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e ListWatchesResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e ListWatchesResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e ListWatchesResponseValidationError) ErrorName() string {
+	return "ListWatchesResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e ListWatchesResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sListWatchesResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = ListWatchesResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = ListWatchesResponseValidationError{}
+
+// Validate checks the field values on Endpoint with the rules defined in the
+// proto definition for this message. If any rules are violated, an error is returned.
+func (m *Endpoint) Validate() error {
+	if m == nil {
+		return nil
+	}
+
+	// no validation rules for Hostname
+
+	// no validation rules for Port
+
+	// no validation rules for Weight
+
+	if v, ok := interface{}(m.GetConditions()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return EndpointValidationError{
+				field:  "Conditions",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	// no validation rules for Priority
+
+	return nil
+}
+
+// EndpointValidationError is the validation error returned by
+// Endpoint.Validate if the designated constraints aren't met.
+type EndpointValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e EndpointValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e EndpointValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e EndpointValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e EndpointValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e EndpointValidationError) ErrorName() string { return "EndpointValidationError" }
+
+// Error satisfies the builtin error interface
+func (e EndpointValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sEndpoint.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = EndpointValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = EndpointValidationError{}
+
+// Validate checks the field values on WatchRequest_SRVRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, an error is returned.
+func (m *WatchRequest_SRVRequest) Validate() error {
+	if m == nil {
+		return nil
+	}
+
+	if len(m.GetService()) > 253 {
+		return WatchRequest_SRVRequestValidationError{
+			field:  "Service",
+			reason: "value length must be at most 253 bytes",
+		}
+	}
+
+	if !_WatchRequest_SRVRequest_Service_Pattern.MatchString(m.GetService()) {
+		return WatchRequest_SRVRequestValidationError{
+			field:  "Service",
+			reason: "value does not match regex pattern \"^[a-z0-9]([a-z0-9-\\\\.]{0,251}[a-z0-9])?$\"",
+		}
+	}
+
+	if !_WatchRequest_SRVRequest_Protocol_Pattern.MatchString(m.GetProtocol()) {
+		return WatchRequest_SRVRequestValidationError{
+			field:  "Protocol",
+			reason: "value does not match regex pattern \"^((tcp)|(udp))$\"",
+		}
+	}
+
+	if len(m.GetDnsName()) > 253 {
+		return WatchRequest_SRVRequestValidationError{
+			field:  "DnsName",
+			reason: "value length must be at most 253 bytes",
+		}
+	}
+
+	if !_WatchRequest_SRVRequest_DnsName_Pattern.MatchString(m.GetDnsName()) {
+		return WatchRequest_SRVRequestValidationError{
+			field:  "DnsName",
+			reason: "value does not match regex pattern \"^[a-z0-9]([a-z0-9-\\\\.]{0,251}[a-z0-9])?$\"",
+		}
+	}
+
+	if _, ok := WatchRequest_SRVRequest_PriorityTiers_name[int32(m.GetPriorityTiers())]; !ok {
+		return WatchRequest_SRVRequestValidationError{
+			field:  "PriorityTiers",
+			reason: "value must be one of the defined enum values",
+		}
+	}
+
+	// no validation rules for ResolveAddresses
+
+	if d := m.GetPollInterval(); d != nil {
+		dur, err := d.AsDuration(), d.CheckValid()
+		if err != nil {
+			return WatchRequest_SRVRequestValidationError{
+				field:  "PollInterval",
+				reason: "value is not a valid duration",
+				cause:  err,
+			}
+		}
+
+		gt := time.Duration(0*time.Second + 0*time.Nanosecond)
+
+		if dur <= gt {
+			return WatchRequest_SRVRequestValidationError{
+				field:  "PollInterval",
+				reason: "value must be greater than 0s",
+			}
+		}
+
+	}
+
+	return nil
+}
+
+// WatchRequest_SRVRequestValidationError is the validation error returned by
+// WatchRequest_SRVRequest.Validate if the designated constraints aren't met.
+type WatchRequest_SRVRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e WatchRequest_SRVRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e WatchRequest_SRVRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e WatchRequest_SRVRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e WatchRequest_SRVRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e WatchRequest_SRVRequestValidationError) ErrorName() string {
+	return "WatchRequest_SRVRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e WatchRequest_SRVRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sWatchRequest_SRVRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = WatchRequest_SRVRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = WatchRequest_SRVRequestValidationError{}
+
+var _WatchRequest_SRVRequest_Service_Pattern = regexp.MustCompile("^[a-z0-9]([a-z0-9-\\.]{0,251}[a-z0-9])?$")
+
+var _WatchRequest_SRVRequest_Protocol_Pattern = regexp.MustCompile("^((tcp)|(udp))$")
+
+var _WatchRequest_SRVRequest_DnsName_Pattern = regexp.MustCompile("^[a-z0-9]([a-z0-9-\\.]{0,251}[a-z0-9])?$")
+
+// Validate checks the field values on WatchRequest_KubernetesRequest with the
+// rules defined in the proto definition for this message. If any rules are
+// violated, an error is returned.
+func (m *WatchRequest_KubernetesRequest) Validate() error {
+	if m == nil {
+		return nil
+	}
+
+	if len(m.GetNamespace()) > 63 {
+		return WatchRequest_KubernetesRequestValidationError{
+			field:  "Namespace",
+			reason: "value length must be at most 63 bytes",
+		}
+	}
+
+	if !_WatchRequest_KubernetesRequest_Namespace_Pattern.MatchString(m.GetNamespace()) {
+		return WatchRequest_KubernetesRequestValidationError{
+			field:  "Namespace",
+			reason: "value does not match regex pattern \"^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$\"",
+		}
+	}
+
+	if len(m.GetServiceName()) > 63 {
+		return WatchRequest_KubernetesRequestValidationError{
+			field:  "ServiceName",
+			reason: "value length must be at most 63 bytes",
+		}
+	}
+
+	if !_WatchRequest_KubernetesRequest_ServiceName_Pattern.MatchString(m.GetServiceName()) {
+		return WatchRequest_KubernetesRequestValidationError{
+			field:  "ServiceName",
+			reason: "value does not match regex pattern \"^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$\"",
+		}
+	}
+
+	if m.GetPortName() != "" {
+
+		if len(m.GetPortName()) > 15 {
+			return WatchRequest_KubernetesRequestValidationError{
+				field:  "PortName",
+				reason: "value length must be at most 15 bytes",
+			}
+		}
+
+		if !_WatchRequest_KubernetesRequest_PortName_Pattern.MatchString(m.GetPortName()) {
+			return WatchRequest_KubernetesRequestValidationError{
+				field:  "PortName",
+				reason: "value does not match regex pattern \"^[a-z0-9]([a-z0-9-]{0,13}[a-z0-9])?$\"",
+			}
+		}
+
+	}
+
+	// no validation rules for IncludeUnready
+
+	return nil
+}
+
+// WatchRequest_KubernetesRequestValidationError is the validation error
+// returned by WatchRequest_KubernetesRequest.Validate if the designated
+// constraints aren't met.
+type WatchRequest_KubernetesRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e WatchRequest_KubernetesRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e WatchRequest_KubernetesRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e WatchRequest_KubernetesRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e WatchRequest_KubernetesRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e WatchRequest_KubernetesRequestValidationError) ErrorName() string {
+	return "WatchRequest_KubernetesRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e WatchRequest_KubernetesRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sWatchRequest_KubernetesRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = WatchRequest_KubernetesRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = WatchRequest_KubernetesRequestValidationError{}
+
+var _WatchRequest_KubernetesRequest_Namespace_Pattern = regexp.MustCompile("^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$")
+
+var _WatchRequest_KubernetesRequest_ServiceName_Pattern = regexp.MustCompile("^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$")
+
+var _WatchRequest_KubernetesRequest_PortName_Pattern = regexp.MustCompile("^[a-z0-9]([a-z0-9-]{0,13}[a-z0-9])?$")
+
+// Validate checks the field values on WatchRequest_FileRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, an error is returned.
+func (m *WatchRequest_FileRequest) Validate() error {
+	if m == nil {
+		return nil
+	}
+
+	if len(m.GetName()) > 255 {
+		return WatchRequest_FileRequestValidationError{
+			field:  "Name",
+			reason: "value length must be at most 255 bytes",
+		}
+	}
+
+	if !_WatchRequest_FileRequest_Name_Pattern.MatchString(m.GetName()) {
+		return WatchRequest_FileRequestValidationError{
+			field:  "Name",
+			reason: "value does not match regex pattern \"^[a-zA-Z0-9_-][a-zA-Z0-9_.-]*$\"",
+		}
+	}
+
+	return nil
+}
+
+// WatchRequest_FileRequestValidationError is the validation error returned by
+// WatchRequest_FileRequest.Validate if the designated constraints aren't met.
+type WatchRequest_FileRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e WatchRequest_FileRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e WatchRequest_FileRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e WatchRequest_FileRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e WatchRequest_FileRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e WatchRequest_FileRequestValidationError) ErrorName() string {
+	return "WatchRequest_FileRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e WatchRequest_FileRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sWatchRequest_FileRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = WatchRequest_FileRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = WatchRequest_FileRequestValidationError{}
+
+var _WatchRequest_FileRequest_Name_Pattern = regexp.MustCompile("^[a-zA-Z0-9_-][a-zA-Z0-9_.-]*$")
+
+// Validate checks the field values on ListWatchesResponse_Watch with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, an error is returned.
+func (m *ListWatchesResponse_Watch) Validate() error {
+	if m == nil {
+		return nil
+	}
+
+	// no validation rules for Key
+
+	// no validation rules for Clients
+
+	// no validation rules for Revision
+
+	// no validation rules for Endpoints
+
+	return nil
+}
+
+// ListWatchesResponse_WatchValidationError is the validation error returned by
+// ListWatchesResponse_Watch.Validate if the designated constraints aren't met.
+type ListWatchesResponse_WatchValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e ListWatchesResponse_WatchValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e ListWatchesResponse_WatchValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e ListWatchesResponse_WatchValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e ListWatchesResponse_WatchValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e ListWatchesResponse_WatchValidationError) ErrorName() string {
+	return "ListWatchesResponse_WatchValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e ListWatchesResponse_WatchValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sListWatchesResponse_Watch.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = ListWatchesResponse_WatchValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = ListWatchesResponse_WatchValidationError{}
+
+// Validate checks the field values on Endpoint_Conditions with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, an error is returned.
+func (m *Endpoint_Conditions) Validate() error {
+	if m == nil {
+		return nil
+	}
+
+	// no validation rules for Ready
+
+	// no validation rules for Serving
+
+	// no validation rules for Terminating
+
+	return nil
+}
+
+// Endpoint_ConditionsValidationError is the validation error returned by
+// Endpoint_Conditions.Validate if the designated constraints aren't met.
+type Endpoint_ConditionsValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e Endpoint_ConditionsValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e Endpoint_ConditionsValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e Endpoint_ConditionsValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e Endpoint_ConditionsValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e Endpoint_ConditionsValidationError) ErrorName() string {
+	return "Endpoint_ConditionsValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e Endpoint_ConditionsValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sEndpoint_Conditions.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = Endpoint_ConditionsValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = Endpoint_ConditionsValidationError{}
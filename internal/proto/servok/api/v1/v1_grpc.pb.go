@@ -19,6 +19,13 @@ const _ = grpc.SupportPackageIsVersion7
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type EndpointServiceClient interface {
 	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (EndpointService_WatchClient, error)
+	// WatchMany watches several targets on a single stream.
+	WatchMany(ctx context.Context, in *WatchManyRequest, opts ...grpc.CallOption) (EndpointService_WatchManyClient, error)
+	// GetEndpoints returns the current endpoints of a target without holding
+	// a stream open.
+	GetEndpoints(ctx context.Context, in *GetEndpointsRequest, opts ...grpc.CallOption) (*GetEndpointsResponse, error)
+	// ListWatches enumerates the targets that are currently being watched.
+	ListWatches(ctx context.Context, in *ListWatchesRequest, opts ...grpc.CallOption) (*ListWatchesResponse, error)
 }
 
 type endpointServiceClient struct {
@@ -61,11 +68,68 @@ func (x *endpointServiceWatchClient) Recv() (*WatchResponse, error) {
 	return m, nil
 }
 
+func (c *endpointServiceClient) WatchMany(ctx context.Context, in *WatchManyRequest, opts ...grpc.CallOption) (EndpointService_WatchManyClient, error) {
+	stream, err := c.cc.NewStream(ctx, &EndpointService_ServiceDesc.Streams[1], "/servok.api.v1.EndpointService/WatchMany", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &endpointServiceWatchManyClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type EndpointService_WatchManyClient interface {
+	Recv() (*WatchManyResponse, error)
+	grpc.ClientStream
+}
+
+type endpointServiceWatchManyClient struct {
+	grpc.ClientStream
+}
+
+func (x *endpointServiceWatchManyClient) Recv() (*WatchManyResponse, error) {
+	m := new(WatchManyResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *endpointServiceClient) GetEndpoints(ctx context.Context, in *GetEndpointsRequest, opts ...grpc.CallOption) (*GetEndpointsResponse, error) {
+	out := new(GetEndpointsResponse)
+	err := c.cc.Invoke(ctx, "/servok.api.v1.EndpointService/GetEndpoints", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *endpointServiceClient) ListWatches(ctx context.Context, in *ListWatchesRequest, opts ...grpc.CallOption) (*ListWatchesResponse, error) {
+	out := new(ListWatchesResponse)
+	err := c.cc.Invoke(ctx, "/servok.api.v1.EndpointService/ListWatches", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // EndpointServiceServer is the server API for EndpointService service.
 // All implementations must embed UnimplementedEndpointServiceServer
 // for forward compatibility
 type EndpointServiceServer interface {
 	Watch(*WatchRequest, EndpointService_WatchServer) error
+	// WatchMany watches several targets on a single stream.
+	WatchMany(*WatchManyRequest, EndpointService_WatchManyServer) error
+	// GetEndpoints returns the current endpoints of a target without holding
+	// a stream open.
+	GetEndpoints(context.Context, *GetEndpointsRequest) (*GetEndpointsResponse, error)
+	// ListWatches enumerates the targets that are currently being watched.
+	ListWatches(context.Context, *ListWatchesRequest) (*ListWatchesResponse, error)
 	mustEmbedUnimplementedEndpointServiceServer()
 }
 
@@ -76,6 +140,15 @@ type UnimplementedEndpointServiceServer struct {
 func (UnimplementedEndpointServiceServer) Watch(*WatchRequest, EndpointService_WatchServer) error {
 	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
 }
+func (UnimplementedEndpointServiceServer) WatchMany(*WatchManyRequest, EndpointService_WatchManyServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchMany not implemented")
+}
+func (UnimplementedEndpointServiceServer) GetEndpoints(context.Context, *GetEndpointsRequest) (*GetEndpointsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetEndpoints not implemented")
+}
+func (UnimplementedEndpointServiceServer) ListWatches(context.Context, *ListWatchesRequest) (*ListWatchesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListWatches not implemented")
+}
 func (UnimplementedEndpointServiceServer) mustEmbedUnimplementedEndpointServiceServer() {}
 
 // UnsafeEndpointServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -110,19 +183,90 @@ func (x *endpointServiceWatchServer) Send(m *WatchResponse) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _EndpointService_WatchMany_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchManyRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(EndpointServiceServer).WatchMany(m, &endpointServiceWatchManyServer{stream})
+}
+
+type EndpointService_WatchManyServer interface {
+	Send(*WatchManyResponse) error
+	grpc.ServerStream
+}
+
+type endpointServiceWatchManyServer struct {
+	grpc.ServerStream
+}
+
+func (x *endpointServiceWatchManyServer) Send(m *WatchManyResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _EndpointService_GetEndpoints_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetEndpointsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EndpointServiceServer).GetEndpoints(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/servok.api.v1.EndpointService/GetEndpoints",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EndpointServiceServer).GetEndpoints(ctx, req.(*GetEndpointsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EndpointService_ListWatches_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListWatchesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EndpointServiceServer).ListWatches(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/servok.api.v1.EndpointService/ListWatches",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EndpointServiceServer).ListWatches(ctx, req.(*ListWatchesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // EndpointService_ServiceDesc is the grpc.ServiceDesc for EndpointService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
 var EndpointService_ServiceDesc = grpc.ServiceDesc{
 	ServiceName: "servok.api.v1.EndpointService",
 	HandlerType: (*EndpointServiceServer)(nil),
-	Methods:     []grpc.MethodDesc{},
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetEndpoints",
+			Handler:    _EndpointService_GetEndpoints_Handler,
+		},
+		{
+			MethodName: "ListWatches",
+			Handler:    _EndpointService_ListWatches_Handler,
+		},
+	},
 	Streams: []grpc.StreamDesc{
 		{
 			StreamName:    "Watch",
 			Handler:       _EndpointService_Watch_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "WatchMany",
+			Handler:       _EndpointService_WatchMany_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "servok/api/v1/v1.proto",
 }
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"math"
+	"time"
+
+	v1 "github.com/authzed/servok/internal/proto/servok/api/v1"
+)
+
+// flapDampener withholds endpoints that keep appearing and disappearing, as
+// in BGP route flap dampening (RFC 2439). Every time an endpoint appears or
+// disappears it is penalized by one, and its penalty halves every halfLife.
+// Endpoints whose penalty exceeds suppress are withheld until it decays
+// below reuse.
+type flapDampener struct {
+	halfLife time.Duration
+	suppress float64
+	reuse    float64
+
+	observed  bool
+	present   map[string]struct{}
+	penalties map[string]*flapPenalty
+}
+
+type flapPenalty struct {
+	value      float64
+	at         time.Time
+	suppressed bool
+}
+
+// newFlapDampener returns nil, which never withholds endpoints, when
+// halfLife is zero.
+func newFlapDampener(halfLife time.Duration, suppress, reuse float64) *flapDampener {
+	if halfLife <= 0 {
+		return nil
+	}
+	return &flapDampener{
+		halfLife:  halfLife,
+		suppress:  suppress,
+		reuse:     reuse,
+		present:   map[string]struct{}{},
+		penalties: map[string]*flapPenalty{},
+	}
+}
+
+// decayed returns the penalty's value at the given time.
+func (d *flapDampener) decayed(penalty *flapPenalty, now time.Time) float64 {
+	return penalty.value * math.Exp2(-float64(now.Sub(penalty.at))/float64(d.halfLife))
+}
+
+// observe penalizes the endpoints that appeared or disappeared since the
+// previous update from the source. The first update only records which
+// endpoints are present.
+func (d *flapDampener) observe(endpoints []*v1.Endpoint, now time.Time) {
+	if d == nil {
+		return
+	}
+
+	present := make(map[string]struct{}, len(endpoints))
+	for _, endpoint := range endpoints {
+		present[endpointKey(endpoint)] = struct{}{}
+	}
+
+	if d.observed {
+		flapped := func(key string) {
+			penalty, ok := d.penalties[key]
+			if !ok {
+				penalty = &flapPenalty{}
+				d.penalties[key] = penalty
+			}
+			penalty.value = d.decayed(penalty, now) + 1
+			penalty.at = now
+			if penalty.value > d.suppress {
+				penalty.suppressed = true
+			}
+		}
+		for key := range present {
+			if _, ok := d.present[key]; !ok {
+				flapped(key)
+			}
+		}
+		for key := range d.present {
+			if _, ok := present[key]; !ok {
+				flapped(key)
+			}
+		}
+	}
+	d.observed = true
+	d.present = present
+}
+
+// filter returns the endpoints that are not withheld, along with the next
+// time at which a withheld endpoint may be reused, which is zero if none are
+// withheld.
+func (d *flapDampener) filter(endpoints []*v1.Endpoint, now time.Time) ([]*v1.Endpoint, time.Time) {
+	if d == nil {
+		return endpoints, time.Time{}
+	}
+
+	// Penalties that have decayed away are forgotten.
+	for key, penalty := range d.penalties {
+		value := d.decayed(penalty, now)
+		if penalty.suppressed && value < d.reuse {
+			penalty.suppressed = false
+		}
+		if !penalty.suppressed && value < d.reuse/2 {
+			delete(d.penalties, key)
+		}
+	}
+
+	var nextReuse time.Time
+	filtered := make([]*v1.Endpoint, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		penalty, ok := d.penalties[endpointKey(endpoint)]
+		if !ok || !penalty.suppressed {
+			filtered = append(filtered, endpoint)
+			continue
+		}
+
+		// The penalty falls below reuse after log2(value/reuse) half lives.
+		halfLives := math.Log2(penalty.value / d.reuse)
+		reuseAt := penalty.at.Add(time.Duration(halfLives * float64(d.halfLife)))
+		if nextReuse.IsZero() || reuseAt.Before(nextReuse) {
+			nextReuse = reuseAt
+		}
+	}
+	return filtered, nextReuse
+}
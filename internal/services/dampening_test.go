@@ -0,0 +1,64 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	v1 "github.com/authzed/servok/internal/proto/servok/api/v1"
+)
+
+func TestFlapDampener(t *testing.T) {
+	require := require.New(t)
+
+	hosts := func(names ...string) []*v1.Endpoint {
+		endpoints := make([]*v1.Endpoint, 0, len(names))
+		for _, name := range names {
+			endpoints = append(endpoints, &v1.Endpoint{Hostname: name, Port: 50051})
+		}
+		return endpoints
+	}
+	hostnames := func(endpoints []*v1.Endpoint) []string {
+		names := make([]string, 0, len(endpoints))
+		for _, endpoint := range endpoints {
+			names = append(names, endpoint.Hostname)
+		}
+		return names
+	}
+
+	d := newFlapDampener(time.Minute, 2, 1)
+	now := time.Unix(0, 0)
+
+	// The first update doesn't count as endpoints appearing
+	d.observe(hosts("a", "b"), now)
+	filtered, nextReuse := d.filter(hosts("a", "b"), now)
+	require.Equal([]string{"a", "b"}, hostnames(filtered))
+	require.True(nextReuse.IsZero())
+
+	// Disappearing and reappearing exceeds the suppress threshold
+	d.observe(hosts("a"), now)
+	d.observe(hosts("a", "b"), now)
+	filtered, _ = d.filter(hosts("a", "b"), now)
+	require.Equal([]string{"a", "b"}, hostnames(filtered))
+	d.observe(hosts("a"), now)
+	d.observe(hosts("a", "b"), now)
+	filtered, nextReuse = d.filter(hosts("a", "b"), now)
+	require.Equal([]string{"a"}, hostnames(filtered))
+
+	// A penalty of 4 decays below 1 after two half lives
+	require.Equal(now.Add(2*time.Minute), nextReuse)
+	filtered, _ = d.filter(hosts("a", "b"), now.Add(time.Minute))
+	require.Equal([]string{"a"}, hostnames(filtered))
+	filtered, nextReuse = d.filter(hosts("a", "b"), now.Add(2*time.Minute+time.Second))
+	require.Equal([]string{"a", "b"}, hostnames(filtered))
+	require.True(nextReuse.IsZero())
+
+	// Dampening is disabled without a half life
+	disabled := newFlapDampener(0, 2, 1)
+	require.Nil(disabled)
+	disabled.observe(hosts("a"), now)
+	filtered, nextReuse = disabled.filter(hosts("a"), now)
+	require.Equal([]string{"a"}, hostnames(filtered))
+	require.True(nextReuse.IsZero())
+}
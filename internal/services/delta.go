@@ -0,0 +1,68 @@
+package services
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+
+	v1 "github.com/authzed/servok/internal/proto/servok/api/v1"
+)
+
+// deltaResponse returns a response carrying the changes from the previously
+// sent response to the next one. The next response is returned as is, as a
+// snapshot, whenever the delta would be no smaller than it or the endpoints
+// cannot be told apart by their hostname and port.
+func deltaResponse(previous, next *v1.WatchResponse) *v1.WatchResponse {
+	previousByKey, ok := endpointsByKey(previous.Endpoints)
+	if !ok {
+		return next
+	}
+	nextByKey, ok := endpointsByKey(next.Endpoints)
+	if !ok {
+		return next
+	}
+
+	delta := &v1.WatchResponse{
+		Revision:             next.Revision,
+		ResponseType:         v1.WatchResponse_RESPONSE_TYPE_DELTA,
+		SourceStatus:         next.SourceStatus,
+		LastSuccessfulUpdate: next.LastSuccessfulUpdate,
+		SourceError:          next.SourceError,
+	}
+	for _, endpoint := range next.Endpoints {
+		existing, ok := previousByKey[endpointKey(endpoint)]
+		if !ok {
+			delta.Added = append(delta.Added, endpoint)
+		} else if !proto.Equal(existing, endpoint) {
+			delta.Modified = append(delta.Modified, endpoint)
+		}
+	}
+	for _, endpoint := range previous.Endpoints {
+		if _, ok := nextByKey[endpointKey(endpoint)]; !ok {
+			delta.Removed = append(delta.Removed, endpoint)
+		}
+	}
+
+	if len(delta.Added)+len(delta.Removed)+len(delta.Modified) >= len(next.Endpoints) {
+		return next
+	}
+	return delta
+}
+
+// endpointsByKey indexes the endpoints by their key, returning false if any
+// of them share one.
+func endpointsByKey(endpoints []*v1.Endpoint) (map[string]*v1.Endpoint, bool) {
+	byKey := make(map[string]*v1.Endpoint, len(endpoints))
+	for _, endpoint := range endpoints {
+		key := endpointKey(endpoint)
+		if _, ok := byKey[key]; ok {
+			return nil, false
+		}
+		byKey[key] = endpoint
+	}
+	return byKey, true
+}
+
+func endpointKey(endpoint *v1.Endpoint) string {
+	return fmt.Sprintf("%s:%d", endpoint.Hostname, endpoint.Port)
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	v1 "github.com/authzed/servok/internal/proto/servok/api/v1"
+)
+
+func TestDeltaResponse(t *testing.T) {
+	host1 := &v1.Endpoint{Hostname: "host1", Port: 50051, Weight: 1}
+	host1Reweighted := &v1.Endpoint{Hostname: "host1", Port: 50051, Weight: 5}
+	host2 := &v1.Endpoint{Hostname: "host2", Port: 50051, Weight: 1}
+	host3 := &v1.Endpoint{Hostname: "host3", Port: 50051, Weight: 1}
+	host4 := &v1.Endpoint{Hostname: "host4", Port: 50051, Weight: 1}
+	host1OtherPort := &v1.Endpoint{Hostname: "host1", Port: 50052, Weight: 1}
+
+	testCases := []struct {
+		name     string
+		previous []*v1.Endpoint
+		next     []*v1.Endpoint
+		expected *v1.WatchResponse
+	}{
+		{
+			"added",
+			[]*v1.Endpoint{host1, host2},
+			[]*v1.Endpoint{host1, host2, host3},
+			&v1.WatchResponse{
+				Revision:     2,
+				ResponseType: v1.WatchResponse_RESPONSE_TYPE_DELTA,
+				Added:        []*v1.Endpoint{host3},
+			},
+		},
+		{
+			"removed",
+			[]*v1.Endpoint{host1, host2, host3},
+			[]*v1.Endpoint{host1, host3},
+			&v1.WatchResponse{
+				Revision:     2,
+				ResponseType: v1.WatchResponse_RESPONSE_TYPE_DELTA,
+				Removed:      []*v1.Endpoint{host2},
+			},
+		},
+		{
+			"modified",
+			[]*v1.Endpoint{host1, host2, host3},
+			[]*v1.Endpoint{host1Reweighted, host2, host3},
+			&v1.WatchResponse{
+				Revision:     2,
+				ResponseType: v1.WatchResponse_RESPONSE_TYPE_DELTA,
+				Modified:     []*v1.Endpoint{host1Reweighted},
+			},
+		},
+		{
+			"ports are distinct endpoints",
+			[]*v1.Endpoint{host1, host2, host3},
+			[]*v1.Endpoint{host1, host1OtherPort, host2, host3},
+			&v1.WatchResponse{
+				Revision:     2,
+				ResponseType: v1.WatchResponse_RESPONSE_TYPE_DELTA,
+				Added:        []*v1.Endpoint{host1OtherPort},
+			},
+		},
+		{
+			"resync when the delta is no smaller",
+			[]*v1.Endpoint{host1, host2},
+			[]*v1.Endpoint{host3, host4},
+			&v1.WatchResponse{Revision: 2, Endpoints: []*v1.Endpoint{host3, host4}},
+		},
+		{
+			"resync when emptied",
+			[]*v1.Endpoint{host1, host2},
+			nil,
+			&v1.WatchResponse{Revision: 2},
+		},
+		{
+			"resync with duplicate endpoints",
+			[]*v1.Endpoint{host1, host2, host3},
+			[]*v1.Endpoint{host1, host1Reweighted, host2, host3},
+			&v1.WatchResponse{Revision: 2, Endpoints: []*v1.Endpoint{host1, host1Reweighted, host2, host3}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			previous := &v1.WatchResponse{Endpoints: tc.previous, Revision: 1}
+			next := &v1.WatchResponse{Endpoints: tc.next, Revision: 2}
+
+			delta := deltaResponse(previous, next)
+			require.True(t, proto.Equal(tc.expected, delta), "expected %v, got %v", tc.expected, delta)
+		})
+	}
+}
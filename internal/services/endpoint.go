@@ -3,19 +3,101 @@ package services
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/rs/zerolog/log"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	v1 "github.com/authzed/servok/internal/proto/servok/api/v1"
-	"github.com/authzed/servok/internal/sources/srvrecord"
+	"github.com/authzed/servok/internal/sources"
 )
 
-func NewEndpointServicer(shutdownCtx context.Context) (v1.EndpointServiceServer, error) {
-	es := &endpointServicer{shutdownCtx: shutdownCtx, watchers: map[string]*watcher{}}
+// Options configures the behavior of the EndpointService.
+type Options struct {
+	// WatcherLinger is how long a watcher keeps its source running after its
+	// last client has disconnected, so that reconnecting clients can reuse it.
+	WatcherLinger time.Duration
+
+	// MaxSkippedUpdates is how many consecutive updates a client may miss
+	// because it could not keep up before it is disconnected. Zero never
+	// disconnects clients.
+	MaxSkippedUpdates int
+
+	// RevisionHistory is how many responses preceding the latest one each
+	// watcher remembers, so that resuming clients that missed them can be
+	// sent a delta rather than a snapshot.
+	RevisionHistory int
+
+	// StaleAfter is how long a source may fail to refresh its endpoints
+	// before they are reported as stale rather than degraded. Zero never
+	// reports them as stale.
+	StaleAfter time.Duration
+
+	// PanicThreshold is the percentage of a target's endpoints that a single
+	// update may remove before it is held, while the previous endpoints keep
+	// being served as degraded. Updates that would remove every endpoint are
+	// always held. Zero disables the threshold.
+	PanicThreshold float64
+
+	// PanicGracePeriod is how long an update is held before it is sent
+	// anyway, unless the source resolves endpoints within the threshold
+	// first. Zero holds updates until it does.
+	PanicGracePeriod time.Duration
+
+	// Debounce is how long changes from a source are coalesced before they
+	// are sent, counted from the first change since the last send. Zero
+	// sends every change as soon as it is received.
+	Debounce time.Duration
+
+	// FlapHalfLife is how long it takes for the penalty that an endpoint
+	// incurs by appearing or disappearing to halve. Zero disables flap
+	// dampening.
+	FlapHalfLife time.Duration
+
+	// FlapSuppressThreshold is the penalty above which an endpoint is
+	// withheld from clients, and FlapReuseThreshold the penalty below which
+	// it is sent again.
+	FlapSuppressThreshold float64
+	FlapReuseThreshold    float64
+
+	// Probes configures active health checking of endpoints, which is
+	// disabled unless it has a Prober.
+	Probes ProbeOptions
+
+	// Health, when set, receives the health of every watched target, named
+	// by its watch key, and of the EndpointService as a whole, which is not
+	// serving while all of the watched targets are unhealthy.
+	Health HealthReporter
+
+	// HealthRequireResolution keeps the EndpointService not serving until
+	// some watched target has resolved endpoints, rather than serving while
+	// no targets are watched.
+	HealthRequireResolution bool
+}
+
+func NewEndpointServicer(shutdownCtx context.Context, registry *sources.Registry, opts Options) (v1.EndpointServiceServer, error) {
+	if opts.FlapHalfLife > 0 && (opts.FlapReuseThreshold <= 0 || opts.FlapReuseThreshold >= opts.FlapSuppressThreshold) {
+		return nil, fmt.Errorf("flap reuse threshold must be positive and below the suppress threshold, got %v and %v", opts.FlapReuseThreshold, opts.FlapSuppressThreshold)
+	}
+
+	if probes := opts.Probes; probes.Prober != nil && (probes.Interval <= 0 || probes.Timeout <= 0) {
+		return nil, fmt.Errorf("probe interval and timeout must be positive, got %v and %v", probes.Interval, probes.Timeout)
+	}
+	if probes := opts.Probes; probes.Prober != nil && (probes.UnhealthyThreshold < 1 || probes.HealthyThreshold < 1) {
+		return nil, fmt.Errorf("probe thresholds must be at least 1, got %d unhealthy and %d healthy", probes.UnhealthyThreshold, probes.HealthyThreshold)
+	}
+
+	es := &endpointServicer{
+		shutdownCtx: shutdownCtx,
+		registry:    registry,
+		opts:        opts,
+		watchers:    map[string]*watcher{},
+		health:      newHealthTracker(opts.Health, opts.HealthRequireResolution),
+	}
 	return es, nil
 }
 
@@ -24,93 +106,373 @@ type endpointServicer struct {
 	sync.Mutex
 
 	shutdownCtx context.Context
+	registry    *sources.Registry
+	opts        Options
 	watchers    map[string]*watcher
+	health      *healthTracker
 }
 
 func (es *endpointServicer) Watch(request *v1.WatchRequest, stream v1.EndpointService_WatchServer) error {
-	// TODO switch on multiple source types
-	srvRequest := request.GetSrv()
-
-	qualifiedName := fmt.Sprintf("_%s._%s.%s",
-		srvRequest.Service,
-		srvRequest.Protocol,
-		srvRequest.DnsName,
-	)
-	log.Info().Str("dnsName", qualifiedName).Msg("client connected")
-
-	updateChannel := make(chan *v1.WatchResponse)
-	info := &clientInfo{updateChannel: updateChannel}
+	notify := make(chan struct{}, 1)
+	sub, err := es.subscribe(request, notify)
+	if err != nil {
+		return err
+	}
+	defer es.unsubscribe(sub)
+
 	var finalStatus error
+	for es.shutdownCtx.Err() == nil && finalStatus == nil {
+		select {
+		case <-notify:
+			var response *v1.WatchResponse
+			response, finalStatus = sub.next()
+			if response != nil {
+				if err := stream.Send(response); err != nil {
+					log.Info().Err(err).Str("watchKey", sub.watchKey).Msg("client disconnected")
+					finalStatus = status.Errorf(codes.Canceled, "attempted to write to closed client stream")
+				}
+			}
+		case <-stream.Context().Done():
+			log.Info().Str("watchKey", sub.watchKey).Msg("client disconnected cleanly")
+			finalStatus = status.Errorf(codes.Canceled, "client disconnected")
+		case <-es.shutdownCtx.Done():
+			finalStatus = status.Errorf(codes.Unavailable, "server disconnected")
+		}
+	}
+
+	return finalStatus
+}
+
+func (es *endpointServicer) WatchMany(request *v1.WatchManyRequest, stream v1.EndpointService_WatchManyServer) error {
+	if len(request.Targets) == 0 {
+		return status.Errorf(codes.InvalidArgument, "no targets specified")
+	}
+
+	// A single notification channel is shared by every target, which are all
+	// checked for updates whenever it fires.
+	notify := make(chan struct{}, 1)
+	subs := make([]*subscription, 0, len(request.Targets))
+	defer func() {
+		for _, sub := range subs {
+			if sub != nil {
+				es.unsubscribe(sub)
+			}
+		}
+	}()
+	for _, target := range request.Targets {
+		sub, err := es.subscribe(target, notify)
+		if err != nil {
+			return err
+		}
+		subs = append(subs, sub)
+	}
+
+	send := func(i int, watchKey string, response *v1.WatchResponse) error {
+		if err := stream.Send(&v1.WatchManyResponse{TargetIndex: uint32(i), Response: response}); err != nil {
+			log.Info().Err(err).Str("watchKey", watchKey).Msg("client disconnected")
+			return status.Errorf(codes.Canceled, "attempted to write to closed client stream")
+		}
+		return nil
+	}
+
+	remaining := len(subs)
+	var finalStatus error
+	for es.shutdownCtx.Err() == nil && finalStatus == nil {
+		select {
+		case <-notify:
+			for i, sub := range subs {
+				if sub == nil {
+					continue
+				}
+
+				response, err := sub.next()
+				if response != nil {
+					if finalStatus = send(i, sub.watchKey, response); finalStatus != nil {
+						break
+					}
+				}
+				if err != nil {
+					// Only the target whose watch ended is dropped, after a
+					// final response saying why, and the others stay watched.
+					es.unsubscribe(sub)
+					subs[i] = nil
+					remaining--
+
+					ended := &v1.WatchResponse{
+						SourceStatus: v1.WatchResponse_SOURCE_STATUS_ERROR,
+						SourceError:  status.Convert(err).Message(),
+					}
+					if finalStatus = send(i, sub.watchKey, ended); finalStatus != nil {
+						break
+					}
+				}
+			}
+			if finalStatus == nil && remaining == 0 {
+				finalStatus = status.Errorf(codes.Unavailable, "every target's watch has ended")
+			}
+		case <-stream.Context().Done():
+			log.Info().Int("targets", remaining).Msg("client disconnected cleanly")
+			finalStatus = status.Errorf(codes.Canceled, "client disconnected")
+		case <-es.shutdownCtx.Done():
+			finalStatus = status.Errorf(codes.Unavailable, "server disconnected")
+		}
+	}
+
+	return finalStatus
+}
+
+// subscription is a client's membership in the watcher of a single target.
+type subscription struct {
+	request  *v1.WatchRequest
+	watchKey string
+	watcher  *watcher
+	info     *clientInfo
+
+	// lastSent is the last full response the client received, from which
+	// deltas are computed.
+	lastSent *v1.WatchResponse
+}
+
+// subscribe adds a client for the request to the watcher of its target,
+// creating the watcher if there isn't a live one. The client's mailbox
+// signals the notify channel whenever it has something to take.
+func (es *endpointServicer) subscribe(request *v1.WatchRequest, notify chan struct{}) (*subscription, error) {
+	kind, watchKey, err := es.registry.Lookup(request)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "unable to find endpoint source: %s", err)
+	}
+	log.Info().Str("watchKey", watchKey).Msg("client connected")
+
+	info := newClientInfo(request, es.opts.MaxSkippedUpdates, notify)
 
 	es.Lock()
 
-	// Find a watcher for this dnsName
-	watcherForName, ok := es.watchers[qualifiedName]
+	// Find a live watcher for this watch key
+	watcherForName, ok := es.watchers[watchKey]
+	if ok {
+		// We take the watcher lock before leaving this block so that the
+		// watcher can't send an update or close before our client is inserted.
+		watcherForName.Lock()
+		if watcherForName.closed {
+			watcherForName.Unlock()
+			ok = false
+		}
+	}
 	if !ok {
-		// TODO make the polling period configurable, (or in the request?)
-		source, err := srvrecord.NewSrvRecordSource(
-			es.shutdownCtx,
-			srvRequest.Service,
-			srvRequest.Protocol,
-			srvRequest.DnsName,
-			1*time.Second,
-		)
+		watcherCtx, cancel := context.WithCancel(es.shutdownCtx)
+		source, err := kind.New(watcherCtx, request)
 		if err != nil {
+			cancel()
 			es.Unlock()
-			log.Info().Str("dnsName", qualifiedName).Msg("client disconnected")
-			return status.Errorf(codes.InvalidArgument, "unable to initialize endpoint source: %s", err)
+			log.Info().Str("watchKey", watchKey).Msg("client disconnected")
+			return nil, status.Errorf(codes.InvalidArgument, "unable to initialize endpoint source: %s", err)
 		}
 
 		// Create the watcher
+		tuner, _ := kind.(sources.Tuner)
+		var sourceErrs <-chan error
+		if reporter, ok := kind.(sources.Reporter); ok {
+			sourceErrs = reporter.Errors(source)
+		}
 		watcherForName = &watcher{
-			shutdownCtx:  es.shutdownCtx,
-			lastResponse: &v1.WatchResponse{},
+			watchKey:         watchKey,
+			shutdownCtx:      watcherCtx,
+			cancel:           cancel,
+			source:           source,
+			tuner:            tuner,
+			health:           es.health,
+			sourceErrs:       sourceErrs,
+			staleAfter:       es.opts.StaleAfter,
+			panicThreshold:   es.opts.PanicThreshold,
+			panicGracePeriod: es.opts.PanicGracePeriod,
+			debounce:         es.opts.Debounce,
+			dampener:         newFlapDampener(es.opts.FlapHalfLife, es.opts.FlapSuppressThreshold, es.opts.FlapReuseThreshold),
+			prober:           newEndpointProber(watcherCtx, watchKey, es.opts.Probes),
+			historySize:      es.opts.RevisionHistory,
+
+			// Revisions start from the current time so that they keep
+			// increasing when a watcher is recreated, and clients resuming
+			// from a previous watcher never match one of its revisions.
+			revision: uint64(time.Now().UnixNano()),
 		}
-		es.watchers[qualifiedName] = watcherForName
+		es.watchers[watchKey] = watcherForName
+		watchersGauge.Set(float64(len(es.watchers)))
 
 		// We need to be holding the lock before we kick off the watcher to prevent getting
 		// messages out of order and having the watcher mutate its client list before we can
 		// insert our client.
 		watcherForName.Lock()
-		go watcherForName.run(source)
-	} else {
-		// Since this watcher was already established, send the last response
-		if err := stream.Send(watcherForName.lastResponse); err != nil {
-			log.Info().Err(err).Str("dnsName", qualifiedName).Msg("client disconnected")
-			finalStatus = status.Errorf(codes.Canceled, "attempted to write to closed client stream")
+		go func(w *watcher) {
+			w.run(source)
+			es.evict(watchKey, w, false)
+			w.cancel()
+		}(watcherForName)
+	}
+
+	// Since an established watcher has already received updates, this
+	// immediately queues the last response for the client unless it is
+	// resuming from it.
+	lastSent := watcherForName.addClient(info)
+	watcherForName.Unlock()
+	es.Unlock()
+
+	return &subscription{
+		request:  request,
+		watchKey: watchKey,
+		watcher:  watcherForName,
+		info:     info,
+		lastSent: lastSent,
+	}, nil
+}
+
+// unsubscribe removes the client from its watcher, which is evicted once it
+// has lingered without any clients.
+func (es *endpointServicer) unsubscribe(sub *subscription) {
+	if remaining := sub.watcher.removeClient(sub.info); remaining == 0 {
+		time.AfterFunc(es.opts.WatcherLinger, func() {
+			es.evict(sub.watchKey, sub.watcher, true)
+		})
+	}
+}
+
+// next takes the pending update from the client's mailbox and returns the
+// response that should be sent for it, if any. The returned status is set
+// when the subscription has ended, after sending the response.
+func (sub *subscription) next() (*v1.WatchResponse, error) {
+	update, lagging, closed := sub.info.take()
+	if lagging {
+		log.Info().Str("watchKey", sub.watchKey).Msg("client fell too far behind")
+		return nil, status.Errorf(codes.ResourceExhausted, "client fell too far behind")
+	}
+
+	var response *v1.WatchResponse
+	if update != nil {
+		response = update
+		if sub.request.Delta && sub.lastSent != nil {
+			response = deltaResponse(sub.lastSent, update)
 		}
+		sub.lastSent = update
+	}
 
-		// Here we take the lock to match the invariant that we hold the lock before
-		// leaving the if statement.
-		watcherForName.Lock()
+	if closed {
+		log.Info().Str("watchKey", sub.watchKey).Msg("endpoint source closed")
+		return response, status.Errorf(codes.Unavailable, "endpoint source closed")
 	}
+	return response, nil
+}
 
-	watcherForName.clients = append(watcherForName.clients, info)
-	watcherForName.Unlock()
+func (es *endpointServicer) GetEndpoints(ctx context.Context, request *v1.GetEndpointsRequest) (*v1.GetEndpointsResponse, error) {
+	kind, watchKey, err := es.registry.Lookup(request.Target)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "unable to find endpoint source: %s", err)
+	}
+
+	// Serve the endpoints of a live watcher when there is one.
+	es.Lock()
+	var lastResponse *v1.WatchResponse
+	if w, ok := es.watchers[watchKey]; ok {
+		w.Lock()
+		if !w.closed {
+			lastResponse = w.lastResponse
+		}
+		w.Unlock()
+	}
 	es.Unlock()
+	if lastResponse != nil {
+		return &v1.GetEndpointsResponse{
+			Endpoints:            lastResponse.Endpoints,
+			Revision:             lastResponse.Revision,
+			SourceStatus:         lastResponse.SourceStatus,
+			LastSuccessfulUpdate: lastResponse.LastSuccessfulUpdate,
+			SourceError:          lastResponse.SourceError,
+		}, nil
+	}
 
-	for es.shutdownCtx.Err() == nil && finalStatus == nil {
+	// Otherwise start a source just long enough to receive its first update.
+	sourceCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	source, err := kind.New(sourceCtx, request.Target)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "unable to initialize endpoint source: %s", err)
+	}
+
+	var sourceErrs <-chan error
+	if reporter, ok := kind.(sources.Reporter); ok {
+		sourceErrs = reporter.Errors(source)
+	}
+
+	for {
 		select {
-		case update, ok := <-updateChannel:
+		case endpoints, ok := <-source:
 			if !ok {
-				finalStatus = status.Errorf(codes.Internal, "attempted to read from closed update channel")
-				break
+				return nil, status.Errorf(codes.Unavailable, "endpoint source closed")
 			}
-			if err := stream.Send(update); err != nil {
-				log.Info().Err(err).Str("dnsName", qualifiedName).Msg("client disconnected")
-				finalStatus = status.Errorf(codes.Canceled, "attempted to write to closed client stream")
+			return &v1.GetEndpointsResponse{Endpoints: endpoints, LastSuccessfulUpdate: timestamppb.Now()}, nil
+		case err := <-sourceErrs:
+			// Sources that fail before their first update may succeed if the
+			// call is retried.
+			if err != nil {
+				return nil, status.Errorf(codes.Unavailable, "unable to resolve endpoints: %s", err)
 			}
-		case <-stream.Context().Done():
-			log.Info().Str("dnsName", qualifiedName).Msg("client disconnected cleanly")
-			finalStatus = status.Errorf(codes.Canceled, "client disconnected")
+		case <-ctx.Done():
+			return nil, status.FromContextError(ctx.Err()).Err()
 		case <-es.shutdownCtx.Done():
-			finalStatus = status.Errorf(codes.Unavailable, "server disconnected")
+			return nil, status.Errorf(codes.Unavailable, "server disconnected")
 		}
 	}
+}
 
-	info.Lock()
-	defer info.Unlock()
-	info.finished = true
+func (es *endpointServicer) ListWatches(ctx context.Context, _ *v1.ListWatchesRequest) (*v1.ListWatchesResponse, error) {
+	es.Lock()
+	defer es.Unlock()
 
-	return finalStatus
+	response := &v1.ListWatchesResponse{}
+	for watchKey, w := range es.watchers {
+		w.Lock()
+		if !w.closed {
+			watch := &v1.ListWatchesResponse_Watch{Key: watchKey, Clients: uint32(len(w.clients))}
+			if w.lastResponse != nil {
+				watch.Revision = w.lastResponse.Revision
+				watch.Endpoints = uint32(len(w.lastResponse.Endpoints))
+			}
+			response.Watches = append(response.Watches, watch)
+		}
+		w.Unlock()
+	}
+
+	sort.Slice(response.Watches, func(i, j int) bool {
+		return response.Watches[i].Key < response.Watches[j].Key
+	})
+	return response, nil
+}
+
+// evict removes the watcher for the watch key and stops its source, as long
+// as it is still the current watcher for that key. When onlyIdle is set, the
+// watcher is only evicted if it has no clients.
+func (es *endpointServicer) evict(watchKey string, w *watcher, onlyIdle bool) {
+	es.Lock()
+	defer es.Unlock()
+
+	if es.watchers[watchKey] != w {
+		return
+	}
+
+	w.Lock()
+	idle := len(w.clients) == 0
+	w.Unlock()
+	if onlyIdle && !idle {
+		return
+	}
+
+	log.Info().Str("watchKey", watchKey).Bool("idle", idle).Msg("evicting watcher")
+	delete(es.watchers, watchKey)
+	watchersGauge.Set(float64(len(es.watchers)))
+
+	// Once the watcher is canceled it no longer records metrics or health,
+	// so they can be deleted without being recreated.
+	w.cancel()
+	w.Lock()
+	deleteTargetMetrics(watchKey)
+	es.health.remove(watchKey)
+	w.Unlock()
 }
@@ -0,0 +1,788 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/authzed/servok/internal/probe"
+	v1 "github.com/authzed/servok/internal/proto/servok/api/v1"
+	"github.com/authzed/servok/internal/services/servicestest"
+	"github.com/authzed/servok/internal/sources"
+	"github.com/authzed/servok/internal/sources/srvrecord"
+)
+
+type tuningKind struct {
+	servicestest.Kind
+	tuned []int
+}
+
+func (k *tuningKind) Tune(_ sources.Endpoint, requests []*v1.WatchRequest) {
+	k.Lock()
+	defer k.Unlock()
+	k.tuned = append(k.tuned, len(requests))
+}
+
+func (k *tuningKind) tunedClients() []int {
+	k.Lock()
+	defer k.Unlock()
+	return append([]int(nil), k.tuned...)
+}
+
+func newTestClient(t *testing.T, kind sources.Kind, opts Options) v1.EndpointServiceClient {
+	conn := servicestest.Dial(t, kind, func(ctx context.Context, registry *sources.Registry, server *grpc.Server) error {
+		servicer, err := NewEndpointServicer(ctx, registry, opts)
+		if err != nil {
+			return err
+		}
+		v1.RegisterEndpointServiceServer(server, servicer)
+		return nil
+	})
+	return v1.NewEndpointServiceClient(conn)
+}
+
+func srvRequest(dnsName string) *v1.WatchRequest {
+	return &v1.WatchRequest{
+		RequestTypeOneof: &v1.WatchRequest_Srv{Srv: &v1.WatchRequest_SRVRequest{
+			Service:  "grpc",
+			Protocol: "tcp",
+			DnsName:  dnsName,
+		}},
+	}
+}
+
+func TestWatchSharesWatchers(t *testing.T) {
+	require := require.New(t)
+
+	kind := &servicestest.Kind{}
+	client := newTestClient(t, kind, Options{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	first, err := client.Watch(ctx, srvRequest("example.com"))
+	require.NoError(err)
+	require.Eventually(func() bool { return len(kind.Sources()) == 1 }, 1*time.Second, 1*time.Millisecond)
+	source := kind.Sources()[0]
+
+	source.Updates <- []*v1.Endpoint{{Hostname: "host1", Port: 50051, Weight: 1}}
+	resp, err := first.Recv()
+	require.NoError(err)
+	require.Equal("host1", resp.Endpoints[0].Hostname)
+
+	// A second client immediately receives the last response
+	second, err := client.Watch(ctx, srvRequest("example.com"))
+	require.NoError(err)
+	resp, err = second.Recv()
+	require.NoError(err)
+	require.Equal("host1", resp.Endpoints[0].Hostname)
+	require.Len(kind.Sources(), 1)
+
+	source.Updates <- []*v1.Endpoint{{Hostname: "host2", Port: 50051, Weight: 1}}
+	for _, stream := range []v1.EndpointService_WatchClient{first, second} {
+		resp, err := stream.Recv()
+		require.NoError(err)
+		require.Equal("host2", resp.Endpoints[0].Hostname)
+	}
+}
+
+func TestWatchRecreatesClosedWatchers(t *testing.T) {
+	require := require.New(t)
+
+	kind := &servicestest.Kind{}
+	client := newTestClient(t, kind, Options{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := client.Watch(ctx, srvRequest("example.com"))
+	require.NoError(err)
+	require.Eventually(func() bool { return len(kind.Sources()) == 1 }, 1*time.Second, 1*time.Millisecond)
+
+	close(kind.Sources()[0].Updates)
+	_, err = stream.Recv()
+	require.Equal(codes.Unavailable, status.Code(err))
+
+	stream, err = client.Watch(ctx, srvRequest("example.com"))
+	require.NoError(err)
+	require.Eventually(func() bool { return len(kind.Sources()) == 2 }, 1*time.Second, 1*time.Millisecond)
+
+	kind.Sources()[1].Updates <- []*v1.Endpoint{{Hostname: "host1", Port: 50051, Weight: 1}}
+	resp, err := stream.Recv()
+	require.NoError(err)
+	require.Equal("host1", resp.Endpoints[0].Hostname)
+}
+
+func TestWatchEvictsIdleWatchers(t *testing.T) {
+	testCases := []struct {
+		name            string
+		linger          time.Duration
+		expectedSources int
+	}{
+		{"no linger", 0, 2},
+		{"reconnect within linger", 1 * time.Minute, 1},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			kind := &servicestest.Kind{}
+			client := newTestClient(t, kind, Options{WatcherLinger: tc.linger})
+
+			streamCtx, streamCancel := context.WithCancel(context.Background())
+			_, err := client.Watch(streamCtx, srvRequest("example.com"))
+			require.NoError(err)
+			require.Eventually(func() bool { return len(kind.Sources()) == 1 }, 1*time.Second, 1*time.Millisecond)
+			source := kind.Sources()[0]
+
+			streamCancel()
+			if tc.linger == 0 {
+				require.Eventually(func() bool { return source.Ctx.Err() != nil }, 1*time.Second, 1*time.Millisecond)
+			} else {
+				time.Sleep(10 * time.Millisecond)
+				require.NoError(source.Ctx.Err())
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			_, err = client.Watch(ctx, srvRequest("example.com"))
+			require.NoError(err)
+			require.Eventually(func() bool { return len(kind.Sources()) == tc.expectedSources }, 1*time.Second, 1*time.Millisecond)
+			require.Never(func() bool { return len(kind.Sources()) > tc.expectedSources }, 10*time.Millisecond, 1*time.Millisecond)
+		})
+	}
+}
+
+func TestWatchTunesSources(t *testing.T) {
+	require := require.New(t)
+
+	kind := &tuningKind{}
+	client := newTestClient(t, kind, Options{WatcherLinger: 1 * time.Minute})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, err := client.Watch(ctx, srvRequest("example.com"))
+	require.NoError(err)
+	require.Eventually(func() bool { return len(kind.tunedClients()) == 1 }, 1*time.Second, 1*time.Millisecond)
+
+	secondCtx, secondCancel := context.WithCancel(context.Background())
+	_, err = client.Watch(secondCtx, srvRequest("example.com"))
+	require.NoError(err)
+	require.Eventually(func() bool { return len(kind.tunedClients()) == 2 }, 1*time.Second, 1*time.Millisecond)
+
+	secondCancel()
+	require.Eventually(func() bool { return len(kind.tunedClients()) == 3 }, 1*time.Second, 1*time.Millisecond)
+	require.Equal([]int{1, 2, 1}, kind.tunedClients())
+}
+
+func TestWatchDelta(t *testing.T) {
+	require := require.New(t)
+
+	kind := &servicestest.Kind{}
+	client := newTestClient(t, kind, Options{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	request := srvRequest("example.com")
+	request.Delta = true
+	stream, err := client.Watch(ctx, request)
+	require.NoError(err)
+	require.Eventually(func() bool { return len(kind.Sources()) == 1 }, 1*time.Second, 1*time.Millisecond)
+	source := kind.Sources()[0]
+
+	host1 := &v1.Endpoint{Hostname: "host1", Port: 50051, Weight: 1}
+	host2 := &v1.Endpoint{Hostname: "host2", Port: 50051, Weight: 1}
+	host3 := &v1.Endpoint{Hostname: "host3", Port: 50051, Weight: 1}
+
+	// The first response is always a snapshot
+	source.Updates <- []*v1.Endpoint{host1, host2}
+	resp, err := stream.Recv()
+	require.NoError(err)
+	require.Equal(v1.WatchResponse_RESPONSE_TYPE_SNAPSHOT, resp.ResponseType)
+	require.Len(resp.Endpoints, 2)
+	revision := resp.Revision
+
+	source.Updates <- []*v1.Endpoint{host1, host2, host3}
+	resp, err = stream.Recv()
+	require.NoError(err)
+	require.Equal(v1.WatchResponse_RESPONSE_TYPE_DELTA, resp.ResponseType)
+	require.Equal(revision+1, resp.Revision)
+	require.Empty(resp.Endpoints)
+	require.Len(resp.Added, 1)
+	require.Equal("host3", resp.Added[0].Hostname)
+
+	source.Updates <- []*v1.Endpoint{host1, host3}
+	resp, err = stream.Recv()
+	require.NoError(err)
+	require.Equal(v1.WatchResponse_RESPONSE_TYPE_DELTA, resp.ResponseType)
+	require.Equal(revision+2, resp.Revision)
+	require.Len(resp.Removed, 1)
+	require.Equal("host2", resp.Removed[0].Hostname)
+}
+
+func TestWatchSourceStatus(t *testing.T) {
+	require := require.New(t)
+
+	kind := &servicestest.Kind{}
+	client := newTestClient(t, kind, Options{StaleAfter: 50 * time.Millisecond})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	request := srvRequest("example.com")
+	request.Delta = true
+	stream, err := client.Watch(ctx, request)
+	require.NoError(err)
+	require.Eventually(func() bool { return len(kind.Sources()) == 1 }, 1*time.Second, 1*time.Millisecond)
+	source := kind.Sources()[0]
+
+	expectStatus := func(expected v1.WatchResponse_SourceStatus) *v1.WatchResponse {
+		resp, err := stream.Recv()
+		require.NoError(err)
+		require.Equal(expected, resp.SourceStatus)
+		return resp
+	}
+
+	// Sources that fail before resolving anything are errors
+	source.Errs <- errors.New("server misbehaving")
+	resp := expectStatus(v1.WatchResponse_SOURCE_STATUS_ERROR)
+	require.Empty(resp.Endpoints)
+	require.Equal("server misbehaving", resp.SourceError)
+	require.Nil(resp.LastSuccessfulUpdate)
+
+	source.Updates <- []*v1.Endpoint{{Hostname: "host1", Port: 50051}}
+	resp = expectStatus(v1.WatchResponse_SOURCE_STATUS_OK)
+	require.Len(resp.Endpoints, 1)
+	require.Empty(resp.SourceError)
+	require.NotNil(resp.LastSuccessfulUpdate)
+	lastSuccess := resp.LastSuccessfulUpdate.AsTime()
+
+	// Failing sources keep serving their last known endpoints, which become
+	// stale after a while.
+	source.Errs <- errors.New("server misbehaving")
+	resp = expectStatus(v1.WatchResponse_SOURCE_STATUS_DEGRADED)
+	require.Equal(v1.WatchResponse_RESPONSE_TYPE_DELTA, resp.ResponseType)
+	require.Empty(resp.Added)
+	require.Empty(resp.Removed)
+	require.Equal("server misbehaving", resp.SourceError)
+	require.Equal(lastSuccess, resp.LastSuccessfulUpdate.AsTime())
+
+	resp = expectStatus(v1.WatchResponse_SOURCE_STATUS_STALE)
+	require.Equal(lastSuccess, resp.LastSuccessfulUpdate.AsTime())
+
+	source.Errs <- nil
+	resp = expectStatus(v1.WatchResponse_SOURCE_STATUS_OK)
+	require.Empty(resp.SourceError)
+}
+
+func TestWatchPanicThreshold(t *testing.T) {
+	require := require.New(t)
+
+	kind := &servicestest.Kind{}
+	client := newTestClient(t, kind, Options{PanicThreshold: 50, PanicGracePeriod: 100 * time.Millisecond})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := client.Watch(ctx, srvRequest("example.com"))
+	require.NoError(err)
+	require.Eventually(func() bool { return len(kind.Sources()) == 1 }, 1*time.Second, 1*time.Millisecond)
+	source := kind.Sources()[0]
+
+	hosts := func(names ...string) []*v1.Endpoint {
+		endpoints := make([]*v1.Endpoint, 0, len(names))
+		for _, name := range names {
+			endpoints = append(endpoints, &v1.Endpoint{Hostname: name, Port: 50051})
+		}
+		return endpoints
+	}
+	expectResponse := func(status v1.WatchResponse_SourceStatus, endpoints int) *v1.WatchResponse {
+		resp, err := stream.Recv()
+		require.NoError(err)
+		require.Equal(status, resp.SourceStatus)
+		require.Len(resp.Endpoints, endpoints)
+		return resp
+	}
+
+	source.Updates <- hosts("host1", "host2", "host3", "host4")
+	expectResponse(v1.WatchResponse_SOURCE_STATUS_OK, 4)
+
+	// Updates that remove too many endpoints are held
+	source.Updates <- hosts("host1")
+	resp := expectResponse(v1.WatchResponse_SOURCE_STATUS_DEGRADED, 4)
+	require.Equal("update would remove 3 of 4 endpoints (75%)", resp.SourceError)
+
+	// Until the source resolves endpoints within the threshold
+	source.Updates <- hosts("host1", "host2", "host3")
+	expectResponse(v1.WatchResponse_SOURCE_STATUS_OK, 3)
+
+	// Or the grace period ends
+	source.Updates <- hosts()
+	resp = expectResponse(v1.WatchResponse_SOURCE_STATUS_DEGRADED, 3)
+	require.Equal("update would remove all 3 endpoints", resp.SourceError)
+	expectResponse(v1.WatchResponse_SOURCE_STATUS_OK, 0)
+}
+
+func TestWatchDebounce(t *testing.T) {
+	require := require.New(t)
+
+	kind := &servicestest.Kind{}
+	client := newTestClient(t, kind, Options{Debounce: 50 * time.Millisecond})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := client.Watch(ctx, srvRequest("example.com"))
+	require.NoError(err)
+	require.Eventually(func() bool { return len(kind.Sources()) == 1 }, 1*time.Second, 1*time.Millisecond)
+	source := kind.Sources()[0]
+
+	// The first endpoints are sent immediately
+	source.Updates <- []*v1.Endpoint{{Hostname: "host1", Port: 50051}}
+	resp, err := stream.Recv()
+	require.NoError(err)
+	require.Len(resp.Endpoints, 1)
+	first := resp.Revision
+
+	// Changes within the window are coalesced
+	start := time.Now()
+	source.Updates <- []*v1.Endpoint{{Hostname: "host1", Port: 50051}, {Hostname: "host2", Port: 50051}}
+	source.Updates <- []*v1.Endpoint{{Hostname: "host2", Port: 50051}}
+	source.Updates <- []*v1.Endpoint{{Hostname: "host2", Port: 50051}, {Hostname: "host3", Port: 50051}}
+	resp, err = stream.Recv()
+	require.NoError(err)
+	require.GreaterOrEqual(int64(time.Since(start)), int64(50*time.Millisecond))
+	require.Equal(first+1, resp.Revision)
+	require.Len(resp.Endpoints, 2)
+	require.Equal("host2", resp.Endpoints[0].Hostname)
+	require.Equal("host3", resp.Endpoints[1].Hostname)
+}
+
+func TestWatchFlapDampening(t *testing.T) {
+	require := require.New(t)
+
+	kind := &servicestest.Kind{}
+	client := newTestClient(t, kind, Options{
+		FlapHalfLife:          100 * time.Millisecond,
+		FlapSuppressThreshold: 2,
+		FlapReuseThreshold:    1,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := client.Watch(ctx, srvRequest("example.com"))
+	require.NoError(err)
+	require.Eventually(func() bool { return len(kind.Sources()) == 1 }, 1*time.Second, 1*time.Millisecond)
+	source := kind.Sources()[0]
+
+	stable := &v1.Endpoint{Hostname: "stable", Port: 50051}
+	flapping := &v1.Endpoint{Hostname: "flapping", Port: 50051}
+	expectHosts := func(names ...string) {
+		resp, err := stream.Recv()
+		require.NoError(err)
+		hostnames := make([]string, 0, len(resp.Endpoints))
+		for _, endpoint := range resp.Endpoints {
+			hostnames = append(hostnames, endpoint.Hostname)
+		}
+		require.ElementsMatch(names, hostnames)
+	}
+
+	source.Updates <- []*v1.Endpoint{stable, flapping}
+	expectHosts("stable", "flapping")
+	source.Updates <- []*v1.Endpoint{stable}
+	expectHosts("stable")
+	source.Updates <- []*v1.Endpoint{stable, flapping}
+	expectHosts("stable", "flapping")
+
+	// Once the endpoint has flapped too often it is withheld
+	source.Updates <- []*v1.Endpoint{stable}
+	expectHosts("stable")
+	start := time.Now()
+	source.Updates <- []*v1.Endpoint{stable, flapping}
+
+	// Until its penalty decays
+	expectHosts("stable", "flapping")
+	require.GreaterOrEqual(int64(time.Since(start)), int64(100*time.Millisecond))
+}
+
+func TestWatchProbes(t *testing.T) {
+	for _, filter := range []bool{true, false} {
+		filter := filter
+		t.Run(fmt.Sprintf("filter=%t", filter), func(t *testing.T) {
+			require := require.New(t)
+
+			// Endpoints are probed at their addresses on local listeners
+			listen := func() (*v1.Endpoint, net.Listener) {
+				listener, err := net.Listen("tcp", "127.0.0.1:0")
+				require.NoError(err)
+				port := listener.Addr().(*net.TCPAddr).Port
+				return &v1.Endpoint{Hostname: fmt.Sprintf("host%d", port), Port: uint32(port), Addresses: []string{"127.0.0.1"}}, listener
+			}
+			up, upListener := listen()
+			defer upListener.Close()
+			down, downListener := listen()
+			require.NoError(downListener.Close())
+
+			kind := &servicestest.Kind{}
+			client := newTestClient(t, kind, Options{Probes: ProbeOptions{
+				Prober:             probe.TCP{},
+				Interval:           10 * time.Millisecond,
+				Timeout:            1 * time.Second,
+				UnhealthyThreshold: 1,
+				HealthyThreshold:   1,
+				FilterUnhealthy:    filter,
+			}})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			stream, err := client.Watch(ctx, srvRequest("example.com"))
+			require.NoError(err)
+			require.Eventually(func() bool { return len(kind.Sources()) == 1 }, 1*time.Second, 1*time.Millisecond)
+			source := kind.Sources()[0]
+
+			// Endpoints are sent before they have been probed
+			source.Updates <- []*v1.Endpoint{up, down}
+			resp, err := stream.Recv()
+			require.NoError(err)
+			require.Len(resp.Endpoints, 2)
+
+			// And then without those that fail their probes
+			resp, err = stream.Recv()
+			require.NoError(err)
+			if filter {
+				require.Len(resp.Endpoints, 1)
+				require.Equal(up.Hostname, resp.Endpoints[0].Hostname)
+			} else {
+				require.Len(resp.Endpoints, 2)
+				for _, endpoint := range resp.Endpoints {
+					if endpoint.Hostname == down.Hostname {
+						require.False(endpoint.Conditions.Ready)
+						require.False(endpoint.Conditions.Serving)
+					} else {
+						require.Nil(endpoint.Conditions)
+					}
+				}
+			}
+
+			// Until they recover
+			downListener, err = net.Listen("tcp", probe.Address(down))
+			require.NoError(err)
+			defer downListener.Close()
+			resp, err = stream.Recv()
+			require.NoError(err)
+			require.Len(resp.Endpoints, 2)
+			for _, endpoint := range resp.Endpoints {
+				require.Nil(endpoint.Conditions)
+			}
+		})
+	}
+}
+
+func TestWatchResumes(t *testing.T) {
+	require := require.New(t)
+
+	kind := &servicestest.Kind{}
+	client := newTestClient(t, kind, Options{RevisionHistory: 1})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := client.Watch(ctx, srvRequest("example.com"))
+	require.NoError(err)
+	require.Eventually(func() bool { return len(kind.Sources()) == 1 }, 1*time.Second, 1*time.Millisecond)
+	source := kind.Sources()[0]
+
+	host1 := &v1.Endpoint{Hostname: "host1", Port: 50051, Weight: 1}
+	host2 := &v1.Endpoint{Hostname: "host2", Port: 50051, Weight: 1}
+	host3 := &v1.Endpoint{Hostname: "host3", Port: 50051, Weight: 1}
+	host4 := &v1.Endpoint{Hostname: "host4", Port: 50051, Weight: 1}
+
+	var revisions []uint64
+	for _, update := range [][]*v1.Endpoint{
+		{host1},
+		{host1, host2},
+		{host1, host2, host3},
+	} {
+		source.Updates <- update
+		resp, err := stream.Recv()
+		require.NoError(err)
+		revisions = append(revisions, resp.Revision)
+	}
+
+	resume := func(revision uint64, delta bool) v1.EndpointService_WatchClient {
+		request := srvRequest("example.com")
+		request.LastSeenRevision = revision
+		request.Delta = delta
+		stream, err := client.Watch(ctx, request)
+		require.NoError(err)
+		return stream
+	}
+
+	// Resuming from a remembered revision sends only what changed since
+	behind := resume(revisions[1], true)
+	resp, err := behind.Recv()
+	require.NoError(err)
+	require.Equal(revisions[2], resp.Revision)
+	require.Equal(v1.WatchResponse_RESPONSE_TYPE_DELTA, resp.ResponseType)
+	require.Len(resp.Added, 1)
+	require.Equal("host3", resp.Added[0].Hostname)
+
+	// Clients that don't want deltas, or resume from a revision that has been
+	// forgotten, receive a snapshot
+	for _, stream := range []v1.EndpointService_WatchClient{
+		resume(revisions[1], false),
+		resume(revisions[0], true),
+	} {
+		resp, err := stream.Recv()
+		require.NoError(err)
+		require.Equal(revisions[2], resp.Revision)
+		require.Equal(v1.WatchResponse_RESPONSE_TYPE_SNAPSHOT, resp.ResponseType)
+		require.Len(resp.Endpoints, 3)
+	}
+
+	// Resuming from the current revision skips the initial response entirely
+	current := resume(revisions[2], true)
+	time.Sleep(10 * time.Millisecond) // let the client join the watcher
+	source.Updates <- []*v1.Endpoint{host1, host2, host3, host4}
+	resp, err = current.Recv()
+	require.NoError(err)
+	require.Equal(revisions[2]+1, resp.Revision)
+	require.Equal(v1.WatchResponse_RESPONSE_TYPE_DELTA, resp.ResponseType)
+	require.Len(resp.Added, 1)
+	require.Equal("host4", resp.Added[0].Hostname)
+}
+
+func TestGetEndpoints(t *testing.T) {
+	require := require.New(t)
+
+	kind := &servicestest.Kind{}
+	client := newTestClient(t, kind, Options{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Without a watcher, a source is started for the request alone
+	type result struct {
+		resp *v1.GetEndpointsResponse
+		err  error
+	}
+	results := make(chan result, 1)
+	go func() {
+		resp, err := client.GetEndpoints(ctx, &v1.GetEndpointsRequest{Target: srvRequest("example.com")})
+		results <- result{resp, err}
+	}()
+	require.Eventually(func() bool { return len(kind.Sources()) == 1 }, 1*time.Second, 1*time.Millisecond)
+	oneShot := kind.Sources()[0]
+	oneShot.Updates <- []*v1.Endpoint{{Hostname: "host1", Port: 50051, Weight: 1}}
+
+	got := <-results
+	require.NoError(got.err)
+	require.Equal(uint64(0), got.resp.Revision)
+	require.Equal("host1", got.resp.Endpoints[0].Hostname)
+	require.Eventually(func() bool { return oneShot.Ctx.Err() != nil }, 1*time.Second, 1*time.Millisecond)
+
+	// With a watcher, its last response is served
+	stream, err := client.Watch(ctx, srvRequest("example.com"))
+	require.NoError(err)
+	require.Eventually(func() bool { return len(kind.Sources()) == 2 }, 1*time.Second, 1*time.Millisecond)
+	kind.Sources()[1].Updates <- []*v1.Endpoint{{Hostname: "host2", Port: 50051, Weight: 1}}
+	watched, err := stream.Recv()
+	require.NoError(err)
+
+	resp, err := client.GetEndpoints(ctx, &v1.GetEndpointsRequest{Target: srvRequest("example.com")})
+	require.NoError(err)
+	require.Equal(watched.Revision, resp.Revision)
+	require.Equal("host2", resp.Endpoints[0].Hostname)
+	require.Len(kind.Sources(), 2)
+
+	// Requests that time out before the first update fail
+	timeoutCtx, timeoutCancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer timeoutCancel()
+	_, err = client.GetEndpoints(timeoutCtx, &v1.GetEndpointsRequest{Target: srvRequest("other.example.com")})
+	require.Equal(codes.DeadlineExceeded, status.Code(err))
+}
+
+// countingResolver answers every SRV lookup with one record, or with err when
+// it is set, and counts the lookups.
+type countingResolver struct {
+	sync.Mutex
+	lookups int
+	err     error
+}
+
+func (r *countingResolver) LookupSRV(context.Context, string, string, string) ([]*net.SRV, time.Duration, error) {
+	r.Lock()
+	defer r.Unlock()
+	r.lookups++
+	return []*net.SRV{{Target: "host1.example.com.", Port: 50051, Weight: 1}}, 0, r.err
+}
+
+func (r *countingResolver) LookupHost(context.Context, string) ([]string, time.Duration, error) {
+	return []string{"10.0.0.1"}, 0, nil
+}
+
+func (r *countingResolver) setErr(err error) {
+	r.Lock()
+	defer r.Unlock()
+	r.err = err
+}
+
+func (r *countingResolver) count() int {
+	r.Lock()
+	defer r.Unlock()
+	return r.lookups
+}
+
+func TestGetEndpointsResolvesSRVImmediately(t *testing.T) {
+	require := require.New(t)
+
+	resolver := &countingResolver{}
+	client := newTestClient(t, &srvrecord.Kind{
+		UpdatePeriod:    1 * time.Minute,
+		MaxUpdatePeriod: 1 * time.Minute,
+		Backoff:         srvrecord.Backoff{Initial: 1 * time.Minute, Max: 1 * time.Minute},
+		Resolver:        resolver,
+	}, Options{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// A cold request is answered by a single lookup, without waiting for the
+	// poll interval
+	request := srvRequest("example.com")
+	request.GetSrv().PollInterval = durationpb.New(1 * time.Minute)
+	start := time.Now()
+	resp, err := client.GetEndpoints(ctx, &v1.GetEndpointsRequest{Target: request})
+	require.NoError(err)
+	require.Less(int64(time.Since(start)), int64(1*time.Second))
+	require.Len(resp.Endpoints, 1)
+	require.Equal("host1.example.com.", resp.Endpoints[0].Hostname)
+	require.Equal(1, resolver.count())
+
+	// Failed lookups are returned as retryable errors
+	resolver.setErr(&net.DNSError{Err: "server misbehaving", IsTemporary: true})
+	_, err = client.GetEndpoints(ctx, &v1.GetEndpointsRequest{Target: srvRequest("servfail.example.com")})
+	require.Equal(codes.Unavailable, status.Code(err))
+}
+
+func TestListWatches(t *testing.T) {
+	require := require.New(t)
+
+	kind := &servicestest.Kind{}
+	client := newTestClient(t, kind, Options{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resp, err := client.ListWatches(ctx, &v1.ListWatchesRequest{})
+	require.NoError(err)
+	require.Empty(resp.Watches)
+
+	_, err = client.Watch(ctx, srvRequest("b.example.com"))
+	require.NoError(err)
+	require.Eventually(func() bool { return len(kind.Sources()) == 1 }, 1*time.Second, 1*time.Millisecond)
+	kind.Sources()[0].Updates <- []*v1.Endpoint{{Hostname: "host1", Port: 50051, Weight: 1}}
+
+	for i := 0; i < 2; i++ {
+		_, err := client.Watch(ctx, srvRequest("a.example.com"))
+		require.NoError(err)
+	}
+
+	require.Eventually(func() bool {
+		resp, err = client.ListWatches(ctx, &v1.ListWatchesRequest{})
+		require.NoError(err)
+		return len(resp.Watches) == 2 && resp.Watches[0].Clients == 2 && resp.Watches[1].Revision != 0
+	}, 1*time.Second, 1*time.Millisecond)
+
+	require.Equal("srv:a.example.com", resp.Watches[0].Key)
+	require.Equal(uint32(0), resp.Watches[0].Endpoints)
+	require.Equal("srv:b.example.com", resp.Watches[1].Key)
+	require.Equal(uint32(1), resp.Watches[1].Clients)
+	require.Equal(uint32(1), resp.Watches[1].Endpoints)
+}
+
+func TestWatchMany(t *testing.T) {
+	require := require.New(t)
+
+	kind := &servicestest.Kind{}
+	client := newTestClient(t, kind, Options{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// An existing watcher is shared with the batch
+	single, err := client.Watch(ctx, srvRequest("a.example.com"))
+	require.NoError(err)
+	require.Eventually(func() bool { return len(kind.Sources()) == 1 }, 1*time.Second, 1*time.Millisecond)
+	sourceA := kind.Sources()[0]
+
+	stream, err := client.WatchMany(ctx, &v1.WatchManyRequest{Targets: []*v1.WatchRequest{
+		srvRequest("a.example.com"),
+		srvRequest("b.example.com"),
+	}})
+	require.NoError(err)
+	require.Eventually(func() bool { return len(kind.Sources()) == 2 }, 1*time.Second, 1*time.Millisecond)
+	sourceB := kind.Sources()[1]
+
+	sourceB.Updates <- []*v1.Endpoint{{Hostname: "hostB", Port: 50051, Weight: 1}}
+	resp, err := stream.Recv()
+	require.NoError(err)
+	require.Equal(uint32(1), resp.TargetIndex)
+	require.Equal("hostB", resp.Response.Endpoints[0].Hostname)
+
+	sourceA.Updates <- []*v1.Endpoint{{Hostname: "hostA", Port: 50051, Weight: 1}}
+	resp, err = stream.Recv()
+	require.NoError(err)
+	require.Equal(uint32(0), resp.TargetIndex)
+	require.Equal("hostA", resp.Response.Endpoints[0].Hostname)
+
+	singleResp, err := single.Recv()
+	require.NoError(err)
+	require.Equal("hostA", singleResp.Endpoints[0].Hostname)
+
+	expectEnded := func(index uint32) {
+		resp, err := stream.Recv()
+		require.NoError(err)
+		require.Equal(index, resp.TargetIndex)
+		require.Equal(v1.WatchResponse_SOURCE_STATUS_ERROR, resp.Response.SourceStatus)
+		require.Equal("endpoint source closed", resp.Response.SourceError)
+		require.Empty(resp.Response.Endpoints)
+	}
+
+	// Closing a source ends only its target's watch
+	close(sourceB.Updates)
+	expectEnded(1)
+
+	sourceA.Updates <- []*v1.Endpoint{{Hostname: "hostA2", Port: 50051, Weight: 1}}
+	resp, err = stream.Recv()
+	require.NoError(err)
+	require.Equal(uint32(0), resp.TargetIndex)
+	require.Equal("hostA2", resp.Response.Endpoints[0].Hostname)
+
+	// The batch ends once every target's watch has
+	close(sourceA.Updates)
+	expectEnded(0)
+	_, err = stream.Recv()
+	require.Equal(codes.Unavailable, status.Code(err))
+
+	empty, err := client.WatchMany(ctx, &v1.WatchManyRequest{})
+	require.NoError(err)
+	_, err = empty.Recv()
+	require.Equal(codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,243 @@
+package services
+
+import (
+	"context"
+	"sync"
+
+	"github.com/authzed/grpcutil"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	v1 "github.com/authzed/servok/internal/proto/servok/api/v1"
+)
+
+// HealthReporter receives the health of the EndpointService and of each
+// watched target. It is implemented by HealthServer.
+type HealthReporter interface {
+	SetServingStatus(service string, status healthpb.HealthCheckResponse_ServingStatus)
+
+	// ClearServingStatus forgets the service, which is then unknown.
+	ClearServingStatus(service string)
+}
+
+// healthTracker reports each watched target as a service named by its watch
+// key, which is serving once its source has resolved at least one endpoint
+// and hasn't failed since. The EndpointService itself is not serving while
+// every watched target is unhealthy, so a single watched target that never
+// resolves, such as a misspelled name, is enough to make it not serving.
+//
+// Without any watched targets the EndpointService is serving, unless
+// requireResolution is set, in which case it is not serving until some
+// target has resolved endpoints.
+type healthTracker struct {
+	sync.Mutex
+
+	reporter          HealthReporter
+	requireResolution bool
+	resolved          bool
+	serving           map[string]bool
+}
+
+func newHealthTracker(reporter HealthReporter, requireResolution bool) *healthTracker {
+	if reporter == nil {
+		return nil
+	}
+
+	t := &healthTracker{reporter: reporter, requireResolution: requireResolution, serving: map[string]bool{}}
+	t.reportService()
+	return t
+}
+
+// set records the health of a target.
+func (t *healthTracker) set(watchKey string, serving bool) {
+	if t == nil {
+		return
+	}
+
+	t.Lock()
+	defer t.Unlock()
+
+	if previous, ok := t.serving[watchKey]; ok && previous == serving {
+		return
+	}
+	t.serving[watchKey] = serving
+	if serving {
+		t.resolved = true
+	}
+
+	status := healthpb.HealthCheckResponse_NOT_SERVING
+	if serving {
+		status = healthpb.HealthCheckResponse_SERVING
+	}
+	t.reporter.SetServingStatus(watchKey, status)
+	t.reportService()
+}
+
+// remove forgets a target that is no longer watched.
+func (t *healthTracker) remove(watchKey string) {
+	if t == nil {
+		return
+	}
+
+	t.Lock()
+	defer t.Unlock()
+
+	if _, ok := t.serving[watchKey]; !ok {
+		return
+	}
+	delete(t.serving, watchKey)
+	t.reporter.ClearServingStatus(watchKey)
+	t.reportService()
+}
+
+// reportService reports the health of the EndpointService. The tracker must
+// be locked.
+func (t *healthTracker) reportService() {
+	status := healthpb.HealthCheckResponse_SERVING
+	switch {
+	case t.requireResolution && !t.resolved:
+		status = healthpb.HealthCheckResponse_NOT_SERVING
+	case len(t.serving) > 0:
+		status = healthpb.HealthCheckResponse_NOT_SERVING
+		for _, serving := range t.serving {
+			if serving {
+				status = healthpb.HealthCheckResponse_SERVING
+				break
+			}
+		}
+	}
+	t.reporter.SetServingStatus(v1.EndpointService_ServiceDesc.ServiceName, status)
+}
+
+// HealthServer serves the gRPC health checking protocol like grpc's
+// health.Server, with the server as a whole named by the empty service, but
+// also forgets services when they are cleared, so that the targets that
+// clients once watched don't accumulate. Health checks are exempt from any
+// auth middleware.
+type HealthServer struct {
+	healthpb.UnimplementedHealthServer
+	grpcutil.IgnoreAuthMixin
+	sync.Mutex
+
+	// shutdown keeps every service not serving, ignoring further changes.
+	shutdown bool
+	statuses map[string]healthpb.HealthCheckResponse_ServingStatus
+	watches  map[string]map[chan healthpb.HealthCheckResponse_ServingStatus]struct{}
+}
+
+func NewHealthServer() *HealthServer {
+	return &HealthServer{
+		statuses: map[string]healthpb.HealthCheckResponse_ServingStatus{"": healthpb.HealthCheckResponse_SERVING},
+		watches:  map[string]map[chan healthpb.HealthCheckResponse_ServingStatus]struct{}{},
+	}
+}
+
+func (s *HealthServer) Check(_ context.Context, request *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	servingStatus, ok := s.statuses[request.Service]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "unknown service")
+	}
+	return &healthpb.HealthCheckResponse{Status: servingStatus}, nil
+}
+
+func (s *HealthServer) Watch(request *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	service := request.Service
+	updates := make(chan healthpb.HealthCheckResponse_ServingStatus, 1)
+
+	s.Lock()
+	updates <- s.statusLocked(service)
+	if _, ok := s.watches[service]; !ok {
+		s.watches[service] = map[chan healthpb.HealthCheckResponse_ServingStatus]struct{}{}
+	}
+	s.watches[service][updates] = struct{}{}
+	s.Unlock()
+
+	defer func() {
+		s.Lock()
+		defer s.Unlock()
+		delete(s.watches[service], updates)
+		if len(s.watches[service]) == 0 {
+			delete(s.watches, service)
+		}
+	}()
+
+	var lastSent healthpb.HealthCheckResponse_ServingStatus = -1
+	for {
+		select {
+		case servingStatus := <-updates:
+			if servingStatus == lastSent {
+				continue
+			}
+			lastSent = servingStatus
+			if err := stream.Send(&healthpb.HealthCheckResponse{Status: servingStatus}); err != nil {
+				return status.Error(codes.Canceled, "stream has ended")
+			}
+		case <-stream.Context().Done():
+			return status.Error(codes.Canceled, "stream has ended")
+		}
+	}
+}
+
+// SetServingStatus sets the status of the service, unless the server has been
+// shut down.
+func (s *HealthServer) SetServingStatus(service string, servingStatus healthpb.HealthCheckResponse_ServingStatus) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.shutdown {
+		log.Debug().Str("service", service).Stringer("status", servingStatus).Msg("ignoring health change during shutdown")
+		return
+	}
+	s.statuses[service] = servingStatus
+	s.notifyLocked(service)
+}
+
+// ClearServingStatus forgets the service, unless the server has been shut
+// down.
+func (s *HealthServer) ClearServingStatus(service string) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.shutdown {
+		return
+	}
+	delete(s.statuses, service)
+	s.notifyLocked(service)
+}
+
+// Shutdown reports every service as not serving, and keeps them that way.
+func (s *HealthServer) Shutdown() {
+	s.Lock()
+	defer s.Unlock()
+
+	s.shutdown = true
+	for service := range s.statuses {
+		s.statuses[service] = healthpb.HealthCheckResponse_NOT_SERVING
+		s.notifyLocked(service)
+	}
+}
+
+func (s *HealthServer) statusLocked(service string) healthpb.HealthCheckResponse_ServingStatus {
+	if servingStatus, ok := s.statuses[service]; ok {
+		return servingStatus
+	}
+	return healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+}
+
+// notifyLocked sends the current status of the service to its watches,
+// replacing any status that they haven't sent yet.
+func (s *HealthServer) notifyLocked(service string) {
+	servingStatus := s.statusLocked(service)
+	for updates := range s.watches[service] {
+		select {
+		case <-updates:
+		default:
+		}
+		updates <- servingStatus
+	}
+}
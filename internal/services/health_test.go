@@ -0,0 +1,211 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	grpcstatus "google.golang.org/grpc/status"
+
+	v1 "github.com/authzed/servok/internal/proto/servok/api/v1"
+	"github.com/authzed/servok/internal/services/servicestest"
+	"github.com/authzed/servok/internal/sources"
+)
+
+func TestWatchHealth(t *testing.T) {
+	require := require.New(t)
+
+	healthSrv := NewHealthServer()
+	kind := &servicestest.Kind{}
+	client := newTestClient(t, kind, Options{Health: healthSrv})
+
+	service := v1.EndpointService_ServiceDesc.ServiceName
+	const target = "srv:health.example.com"
+	expectStatus := func(expected map[string]healthpb.HealthCheckResponse_ServingStatus) {
+		require.Eventually(func() bool {
+			for name, status := range expected {
+				resp, err := healthSrv.Check(context.Background(), &healthpb.HealthCheckRequest{Service: name})
+				if status == healthpb.HealthCheckResponse_SERVICE_UNKNOWN {
+					if grpcstatus.Code(err) != codes.NotFound {
+						return false
+					}
+					continue
+				}
+				if err != nil || resp.Status != status {
+					return false
+				}
+			}
+			return true
+		}, 1*time.Second, 1*time.Millisecond)
+	}
+
+	// Without any targets the service is serving, before anything resolved
+	expectStatus(map[string]healthpb.HealthCheckResponse_ServingStatus{service: healthpb.HealthCheckResponse_SERVING})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := client.Watch(ctx, srvRequest("health.example.com"))
+	require.NoError(err)
+	require.Eventually(func() bool { return len(kind.Sources()) == 1 }, 1*time.Second, 1*time.Millisecond)
+	source := kind.Sources()[0]
+
+	// Targets are not serving until they resolve endpoints
+	expectStatus(map[string]healthpb.HealthCheckResponse_ServingStatus{
+		service: healthpb.HealthCheckResponse_NOT_SERVING,
+		target:  healthpb.HealthCheckResponse_NOT_SERVING,
+	})
+
+	source.Updates <- []*v1.Endpoint{{Hostname: "host1", Port: 50051}}
+	_, err = stream.Recv()
+	require.NoError(err)
+	expectStatus(map[string]healthpb.HealthCheckResponse_ServingStatus{
+		service: healthpb.HealthCheckResponse_SERVING,
+		target:  healthpb.HealthCheckResponse_SERVING,
+	})
+
+	// Failing sources are not serving until they recover
+	source.Errs <- errors.New("server misbehaving")
+	expectStatus(map[string]healthpb.HealthCheckResponse_ServingStatus{
+		service: healthpb.HealthCheckResponse_NOT_SERVING,
+		target:  healthpb.HealthCheckResponse_NOT_SERVING,
+	})
+	source.Errs <- nil
+	expectStatus(map[string]healthpb.HealthCheckResponse_ServingStatus{
+		service: healthpb.HealthCheckResponse_SERVING,
+		target:  healthpb.HealthCheckResponse_SERVING,
+	})
+
+	// As are targets without any endpoints
+	source.Updates <- []*v1.Endpoint{}
+	_, err = stream.Recv()
+	require.NoError(err)
+	expectStatus(map[string]healthpb.HealthCheckResponse_ServingStatus{
+		service: healthpb.HealthCheckResponse_NOT_SERVING,
+		target:  healthpb.HealthCheckResponse_NOT_SERVING,
+	})
+
+	// Targets that are no longer watched are forgotten, leaving the service
+	// serving while idle even though the last target was unhealthy
+	cancel()
+	expectStatus(map[string]healthpb.HealthCheckResponse_ServingStatus{
+		service: healthpb.HealthCheckResponse_SERVING,
+		target:  healthpb.HealthCheckResponse_SERVICE_UNKNOWN,
+	})
+}
+
+type recordingReporter map[string]healthpb.HealthCheckResponse_ServingStatus
+
+func (r recordingReporter) SetServingStatus(service string, status healthpb.HealthCheckResponse_ServingStatus) {
+	r[service] = status
+}
+
+func (r recordingReporter) ClearServingStatus(service string) {
+	delete(r, service)
+}
+
+func TestHealthTrackerIdle(t *testing.T) {
+	service := v1.EndpointService_ServiceDesc.ServiceName
+
+	for _, tc := range []struct {
+		name              string
+		requireResolution bool
+		serving           []bool
+		expected          healthpb.HealthCheckResponse_ServingStatus
+		expectedIdle      healthpb.HealthCheckResponse_ServingStatus
+	}{
+		{"no targets", false, nil, healthpb.HealthCheckResponse_SERVING, healthpb.HealthCheckResponse_SERVING},
+		{"never resolved", false, []bool{false}, healthpb.HealthCheckResponse_NOT_SERVING, healthpb.HealthCheckResponse_SERVING},
+		{"resolved", false, []bool{true}, healthpb.HealthCheckResponse_SERVING, healthpb.HealthCheckResponse_SERVING},
+		{"one resolved", false, []bool{false, true}, healthpb.HealthCheckResponse_SERVING, healthpb.HealthCheckResponse_SERVING},
+		{"requiring resolution without targets", true, nil, healthpb.HealthCheckResponse_NOT_SERVING, healthpb.HealthCheckResponse_NOT_SERVING},
+		{"requiring resolution never resolved", true, []bool{false}, healthpb.HealthCheckResponse_NOT_SERVING, healthpb.HealthCheckResponse_NOT_SERVING},
+		{"requiring resolution resolved", true, []bool{true}, healthpb.HealthCheckResponse_SERVING, healthpb.HealthCheckResponse_SERVING},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			reporter := recordingReporter{}
+			tracker := newHealthTracker(reporter, tc.requireResolution)
+			for i, serving := range tc.serving {
+				tracker.set(fmt.Sprintf("srv:%d.example.com", i), serving)
+			}
+			require.Equal(t, tc.expected, reporter[service])
+
+			// Removed targets are forgotten, leaving the service idle
+			for i := range tc.serving {
+				tracker.remove(fmt.Sprintf("srv:%d.example.com", i))
+			}
+			require.Equal(t, tc.expectedIdle, reporter[service])
+			require.Len(t, reporter, 1)
+		})
+	}
+}
+
+func TestHealthServer(t *testing.T) {
+	require := require.New(t)
+
+	server := NewHealthServer()
+	conn := servicestest.Dial(t, &servicestest.Kind{}, func(_ context.Context, _ *sources.Registry, grpcServer *grpc.Server) error {
+		healthpb.RegisterHealthServer(grpcServer, server)
+		return nil
+	})
+	client := healthpb.NewHealthClient(conn)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(err)
+	require.Equal(healthpb.HealthCheckResponse_SERVING, resp.Status)
+
+	const target = "srv:health.example.com"
+	watch, err := client.Watch(ctx, &healthpb.HealthCheckRequest{Service: target})
+	require.NoError(err)
+	expectWatched := func(expected healthpb.HealthCheckResponse_ServingStatus) {
+		resp, err := watch.Recv()
+		require.NoError(err)
+		require.Equal(expected, resp.Status)
+	}
+	expectWatched(healthpb.HealthCheckResponse_SERVICE_UNKNOWN)
+
+	server.SetServingStatus(target, healthpb.HealthCheckResponse_SERVING)
+	expectWatched(healthpb.HealthCheckResponse_SERVING)
+	resp, err = client.Check(ctx, &healthpb.HealthCheckRequest{Service: target})
+	require.NoError(err)
+	require.Equal(healthpb.HealthCheckResponse_SERVING, resp.Status)
+
+	// Cleared services are forgotten rather than kept as unknown
+	server.ClearServingStatus(target)
+	expectWatched(healthpb.HealthCheckResponse_SERVICE_UNKNOWN)
+	_, err = client.Check(ctx, &healthpb.HealthCheckRequest{Service: target})
+	require.Equal(codes.NotFound, grpcstatus.Code(err))
+	server.Lock()
+	require.NotContains(server.statuses, target)
+	server.Unlock()
+
+	// Shutting down stops serving every service for good
+	server.SetServingStatus(target, healthpb.HealthCheckResponse_SERVING)
+	expectWatched(healthpb.HealthCheckResponse_SERVING)
+	server.Shutdown()
+	expectWatched(healthpb.HealthCheckResponse_NOT_SERVING)
+	server.SetServingStatus(target, healthpb.HealthCheckResponse_SERVING)
+	server.ClearServingStatus(target)
+	resp, err = client.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(err)
+	require.Equal(healthpb.HealthCheckResponse_NOT_SERVING, resp.Status)
+	resp, err = client.Check(ctx, &healthpb.HealthCheckRequest{Service: target})
+	require.NoError(err)
+	require.Equal(healthpb.HealthCheckResponse_NOT_SERVING, resp.Status)
+
+	// Watches are forgotten once they end
+	cancel()
+	require.Eventually(func() bool {
+		server.Lock()
+		defer server.Unlock()
+		return len(server.watches) == 0
+	}, 1*time.Second, 1*time.Millisecond)
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Metrics that are labeled by target use the watch key of the target and are
+// removed when its watcher is evicted.
+var (
+	watchersGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "servok",
+		Name:      "watchers",
+		Help:      "Number of targets that are being watched.",
+	})
+
+	watcherClientsGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "servok",
+		Name:      "watcher_clients",
+		Help:      "Number of clients watching each target.",
+	}, []string{"target"})
+
+	targetEndpointsGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "servok",
+		Name:      "target_endpoints",
+		Help:      "Number of endpoints that each target last resolved to.",
+	}, []string{"target"})
+
+	watcherUpdatesCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "servok",
+		Name:      "watcher_updates_total",
+		Help:      "Number of updates that each target's watcher sent to its clients.",
+	}, []string{"target"})
+
+	watcherLastUpdateGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "servok",
+		Name:      "watcher_last_update_timestamp_seconds",
+		Help:      "Unix time at which each target's watcher last received an update.",
+	}, []string{"target"})
+
+	watcherHeldUpdatesCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "servok",
+		Name:      "watcher_held_updates_total",
+		Help:      "Number of updates that each target's watcher held for exceeding the panic threshold.",
+	}, []string{"target"})
+
+	targetSuppressedEndpointsGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "servok",
+		Name:      "target_suppressed_endpoints",
+		Help:      "Number of each target's endpoints that are withheld for flapping.",
+	}, []string{"target"})
+
+	targetUnhealthyEndpointsGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "servok",
+		Name:      "target_unhealthy_endpoints",
+		Help:      "Number of each target's endpoints that failed their health checks.",
+	}, []string{"target"})
+
+	prunedClientsCounter = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "servok",
+		Name:      "pruned_clients_total",
+		Help:      "Number of clients disconnected for falling too far behind their watcher.",
+	})
+)
+
+// deleteTargetMetrics removes the series of a target that is no longer
+// watched.
+func deleteTargetMetrics(watchKey string) {
+	watcherClientsGauge.DeleteLabelValues(watchKey)
+	targetEndpointsGauge.DeleteLabelValues(watchKey)
+	watcherUpdatesCounter.DeleteLabelValues(watchKey)
+	watcherLastUpdateGauge.DeleteLabelValues(watchKey)
+	watcherHeldUpdatesCounter.DeleteLabelValues(watchKey)
+	targetSuppressedEndpointsGauge.DeleteLabelValues(watchKey)
+	targetUnhealthyEndpointsGauge.DeleteLabelValues(watchKey)
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	v1 "github.com/authzed/servok/internal/proto/servok/api/v1"
+	"github.com/authzed/servok/internal/services/servicestest"
+)
+
+// hasTargetSeries returns whether any metric has a series for the target.
+func hasTargetSeries(t *testing.T, target string) bool {
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		for _, metric := range family.Metric {
+			for _, label := range metric.Label {
+				if label.GetName() == "target" && label.GetValue() == target {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func TestWatchMetrics(t *testing.T) {
+	require := require.New(t)
+
+	kind := &servicestest.Kind{}
+	client := newTestClient(t, kind, Options{})
+	const target = "srv:metrics.example.com"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	first, err := client.Watch(ctx, srvRequest("metrics.example.com"))
+	require.NoError(err)
+	require.Eventually(func() bool { return len(kind.Sources()) == 1 }, 1*time.Second, 1*time.Millisecond)
+	source := kind.Sources()[0]
+
+	source.Updates <- []*v1.Endpoint{{Hostname: "host1", Port: 50051}, {Hostname: "host2", Port: 50051}}
+	_, err = first.Recv()
+	require.NoError(err)
+
+	secondCtx, cancelSecond := context.WithCancel(ctx)
+	second, err := client.Watch(secondCtx, srvRequest("metrics.example.com"))
+	require.NoError(err)
+	_, err = second.Recv()
+	require.NoError(err)
+
+	require.Equal(float64(2), testutil.ToFloat64(targetEndpointsGauge.WithLabelValues(target)))
+	require.Equal(float64(1), testutil.ToFloat64(watcherUpdatesCounter.WithLabelValues(target)))
+	require.Equal(float64(2), testutil.ToFloat64(watcherClientsGauge.WithLabelValues(target)))
+	require.NotZero(testutil.ToFloat64(watcherLastUpdateGauge.WithLabelValues(target)))
+	require.Equal(float64(1), testutil.ToFloat64(watchersGauge))
+
+	cancelSecond()
+	require.Eventually(func() bool {
+		return testutil.ToFloat64(watcherClientsGauge.WithLabelValues(target)) == 1
+	}, 1*time.Second, 1*time.Millisecond)
+
+	source.Updates <- []*v1.Endpoint{{Hostname: "host1", Port: 50051}}
+	_, err = first.Recv()
+	require.NoError(err)
+	require.Equal(float64(2), testutil.ToFloat64(watcherUpdatesCounter.WithLabelValues(target)))
+	require.Equal(float64(1), testutil.ToFloat64(targetEndpointsGauge.WithLabelValues(target)))
+
+	cancel()
+
+	// Once the watcher is evicted its series are removed
+	require.Eventually(func() bool { return !hasTargetSeries(t, target) }, 1*time.Second, 1*time.Millisecond)
+	require.Equal(float64(0), testutil.ToFloat64(watchersGauge))
+}
@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/authzed/servok/internal/probe"
+	v1 "github.com/authzed/servok/internal/proto/servok/api/v1"
+)
+
+// ProbeOptions configures active health checking of the endpoints that
+// sources discover.
+type ProbeOptions struct {
+	// Prober checks the health of every endpoint each Interval, taking up to
+	// Timeout. Nil disables health checking.
+	Prober   probe.Prober
+	Interval time.Duration
+	Timeout  time.Duration
+
+	// An endpoint becomes unhealthy after UnhealthyThreshold consecutive
+	// failed probes and healthy again after HealthyThreshold consecutive
+	// successful ones. Endpoints are healthy until they are first probed,
+	// which decides their health regardless of the thresholds.
+	UnhealthyThreshold int
+	HealthyThreshold   int
+
+	// FilterUnhealthy removes unhealthy endpoints from responses, rather
+	// than sending them with conditions that are neither ready nor serving.
+	FilterUnhealthy bool
+}
+
+// endpointProber probes the endpoints of a watcher until they are no longer
+// discovered, and signals changes whenever one of them changes health.
+type endpointProber struct {
+	sync.Mutex
+
+	ctx      context.Context
+	cancel   context.CancelFunc
+	watchKey string
+	opts     ProbeOptions
+	changed  chan struct{}
+	probes   map[string]*endpointProbe
+}
+
+type endpointProbe struct {
+	cancel    context.CancelFunc
+	endpoint  *v1.Endpoint
+	probed    bool
+	healthy   bool
+	successes int
+	failures  int
+}
+
+// newEndpointProber returns nil, which considers every endpoint healthy, when
+// health checking is disabled.
+func newEndpointProber(ctx context.Context, watchKey string, opts ProbeOptions) *endpointProber {
+	if opts.Prober == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	return &endpointProber{
+		ctx:      ctx,
+		cancel:   cancel,
+		watchKey: watchKey,
+		opts:     opts,
+		changed:  make(chan struct{}, 1),
+		probes:   map[string]*endpointProbe{},
+	}
+}
+
+// changes returns a channel that receives whenever an endpoint changes
+// health.
+func (p *endpointProber) changes() <-chan struct{} {
+	if p == nil {
+		return nil
+	}
+	return p.changed
+}
+
+// update starts probing endpoints that weren't discovered before and stops
+// probing those that are no longer discovered.
+func (p *endpointProber) update(endpoints []*v1.Endpoint) {
+	if p == nil {
+		return
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	discovered := make(map[string]struct{}, len(endpoints))
+	for _, endpoint := range endpoints {
+		key := endpointKey(endpoint)
+		discovered[key] = struct{}{}
+		if existing, ok := p.probes[key]; ok {
+			existing.endpoint = endpoint
+			continue
+		}
+
+		ctx, cancel := context.WithCancel(p.ctx)
+		ep := &endpointProbe{cancel: cancel, endpoint: endpoint, healthy: true}
+		p.probes[key] = ep
+		go p.run(ctx, key, ep)
+	}
+
+	for key, ep := range p.probes {
+		if _, ok := discovered[key]; !ok {
+			ep.cancel()
+			delete(p.probes, key)
+		}
+	}
+}
+
+// stop stops probing every endpoint.
+func (p *endpointProber) stop() {
+	if p == nil {
+		return
+	}
+	p.cancel()
+}
+
+func (p *endpointProber) run(ctx context.Context, key string, ep *endpointProbe) {
+	ticker := time.NewTicker(p.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		p.Lock()
+		endpoint := ep.endpoint
+		p.Unlock()
+
+		probeCtx, cancel := context.WithTimeout(ctx, p.opts.Timeout)
+		err := p.opts.Prober.Probe(probeCtx, endpoint)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+		p.record(key, ep, err)
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// record updates the health of an endpoint with the result of a probe.
+func (p *endpointProber) record(key string, ep *endpointProbe, err error) {
+	p.Lock()
+	defer p.Unlock()
+
+	if p.probes[key] != ep {
+		return
+	}
+
+	healthy := ep.healthy
+	if err == nil {
+		ep.successes, ep.failures = ep.successes+1, 0
+		if !ep.probed || ep.successes >= p.opts.HealthyThreshold {
+			healthy = true
+		}
+	} else {
+		ep.successes, ep.failures = 0, ep.failures+1
+		if !ep.probed || ep.failures >= p.opts.UnhealthyThreshold {
+			healthy = false
+		}
+	}
+	ep.probed = true
+
+	if healthy == ep.healthy {
+		return
+	}
+	ep.healthy = healthy
+	if healthy {
+		log.Info().Str("watchKey", p.watchKey).Str("endpoint", key).Msg("endpoint became healthy")
+	} else {
+		log.Warn().Err(err).Str("watchKey", p.watchKey).Str("endpoint", key).Msg("endpoint became unhealthy")
+	}
+
+	select {
+	case p.changed <- struct{}{}:
+	default:
+	}
+}
+
+// apply returns the endpoints without those that are unhealthy or, unless
+// they are filtered, with conditions that mark them as neither ready nor
+// serving, along with how many of them are unhealthy.
+func (p *endpointProber) apply(endpoints []*v1.Endpoint) ([]*v1.Endpoint, int) {
+	if p == nil {
+		return endpoints, 0
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	unhealthy := 0
+	applied := make([]*v1.Endpoint, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		if ep, ok := p.probes[endpointKey(endpoint)]; !ok || ep.healthy {
+			applied = append(applied, endpoint)
+			continue
+		}
+
+		unhealthy++
+		if p.opts.FilterUnhealthy {
+			continue
+		}
+		annotated := proto.Clone(endpoint).(*v1.Endpoint)
+		if annotated.Conditions == nil {
+			annotated.Conditions = &v1.Endpoint_Conditions{}
+		}
+		annotated.Conditions.Ready = false
+		annotated.Conditions.Serving = false
+		applied = append(applied, annotated)
+	}
+	return applied, unhealthy
+}
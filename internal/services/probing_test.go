@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	v1 "github.com/authzed/servok/internal/proto/servok/api/v1"
+)
+
+type failingProber struct{}
+
+func (failingProber) Probe(context.Context, *v1.Endpoint) error {
+	return errors.New("connection refused")
+}
+
+func TestEndpointProberThresholds(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Probes are recorded by hand, so the interval is never reached
+	p := newEndpointProber(ctx, "example.com", ProbeOptions{
+		Prober:             failingProber{},
+		Interval:           1 * time.Hour,
+		Timeout:            1 * time.Hour,
+		UnhealthyThreshold: 2,
+		HealthyThreshold:   2,
+	})
+	defer p.stop()
+
+	endpoint := &v1.Endpoint{Hostname: "host1", Port: 50051}
+	key := endpointKey(endpoint)
+	healthy := func() bool {
+		applied, unhealthy := p.apply([]*v1.Endpoint{endpoint})
+		require.Len(applied, 1)
+		return unhealthy == 0 && applied[0].Conditions == nil
+	}
+
+	// Endpoints are healthy until they are probed
+	p.Lock()
+	p.probes[key] = &endpointProbe{cancel: func() {}, endpoint: endpoint, healthy: true}
+	ep := p.probes[key]
+	p.Unlock()
+	require.True(healthy())
+
+	// The first probe decides their health
+	p.record(key, ep, errors.New("connection refused"))
+	require.False(healthy())
+	<-p.changes()
+
+	// Afterwards it takes consecutive probes to change it
+	p.record(key, ep, nil)
+	require.False(healthy())
+	p.record(key, ep, errors.New("connection refused"))
+	p.record(key, ep, nil)
+	require.False(healthy())
+	p.record(key, ep, nil)
+	require.True(healthy())
+	<-p.changes()
+	p.record(key, ep, errors.New("connection refused"))
+	require.True(healthy())
+	p.record(key, ep, errors.New("connection refused"))
+	require.False(healthy())
+
+	// Unhealthy endpoints are annotated
+	applied, unhealthy := p.apply([]*v1.Endpoint{endpoint})
+	require.Equal(1, unhealthy)
+	require.Equal(&v1.Endpoint_Conditions{}, applied[0].Conditions)
+	require.Nil(endpoint.Conditions)
+
+	// Or filtered
+	p.opts.FilterUnhealthy = true
+	applied, unhealthy = p.apply([]*v1.Endpoint{endpoint})
+	require.Equal(1, unhealthy)
+	require.Empty(applied)
+
+	// Endpoints that are no longer discovered stop being probed
+	p.update(nil)
+	require.Empty(p.probes)
+	p.record(key, ep, nil)
+	applied, _ = p.apply([]*v1.Endpoint{endpoint})
+	require.Len(applied, 1)
+}
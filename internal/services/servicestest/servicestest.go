@@ -0,0 +1,105 @@
+// Package servicestest serves servok's gRPC services to tests over an
+// in-memory connection, with endpoint sources that the tests control.
+package servicestest
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+
+	v1 "github.com/authzed/servok/internal/proto/servok/api/v1"
+	"github.com/authzed/servok/internal/sources"
+)
+
+// Kind serves SRV requests, keyed by their DNS name, with sources that emit
+// whatever tests send them.
+type Kind struct {
+	sync.Mutex
+
+	// Endpoints, when set, are emitted by every source as soon as it is
+	// created.
+	Endpoints []*v1.Endpoint
+
+	created []*Source
+}
+
+// Source is a source created by a Kind. It is stopped once Ctx is done.
+type Source struct {
+	Ctx     context.Context
+	Updates chan []*v1.Endpoint
+	Errs    chan error
+}
+
+func (k *Kind) Key(request *v1.WatchRequest) string {
+	return request.GetSrv().DnsName
+}
+
+func (k *Kind) New(ctx context.Context, _ *v1.WatchRequest) (sources.Endpoint, error) {
+	k.Lock()
+	defer k.Unlock()
+
+	updates := make(chan []*v1.Endpoint)
+	if k.Endpoints != nil {
+		updates = make(chan []*v1.Endpoint, 1)
+		updates <- k.Endpoints
+	}
+	source := &Source{Ctx: ctx, Updates: updates, Errs: make(chan error)}
+	k.created = append(k.created, source)
+	return source.Updates, nil
+}
+
+// Errors returns the channel that tests send the source's failures on.
+func (k *Kind) Errors(source sources.Endpoint) <-chan error {
+	for _, created := range k.Sources() {
+		if sources.Endpoint(created.Updates) == source {
+			return created.Errs
+		}
+	}
+	return nil
+}
+
+// Sources returns the sources that have been created, in order.
+func (k *Kind) Sources() []*Source {
+	k.Lock()
+	defer k.Unlock()
+	return append([]*Source(nil), k.created...)
+}
+
+// Register registers the services under test on the server. They serve
+// requests from the sources in the registry until ctx is done.
+type Register func(ctx context.Context, registry *sources.Registry, server *grpc.Server) error
+
+// Dial serves the services that register registers, with kind serving SRV
+// requests, on an in-memory listener and returns a connection to them. The
+// server and connection are stopped when the test ends.
+func Dial(t testing.TB, kind sources.Kind, register Register, opts ...grpc.ServerOption) *grpc.ClientConn {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	registry := sources.NewRegistry()
+	registry.Register("srv", kind)
+
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(opts...)
+	require.NoError(t, register(ctx, registry, server))
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.DialContext(ctx, "bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return listener.Dial()
+		}),
+		grpc.WithInsecure(),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return conn
+}
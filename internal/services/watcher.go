@@ -2,33 +2,215 @@ package services
 
 import (
 	"context"
+	"fmt"
 	"sync"
+	"time"
 
 	"github.com/rs/zerolog/log"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	v1 "github.com/authzed/servok/internal/proto/servok/api/v1"
 	"github.com/authzed/servok/internal/sources"
 )
 
+// clientInfo is a mailbox holding the latest response that has not yet been
+// sent to a client. Newer responses supersede older ones, so a slow client
+// only ever skips to the most recent snapshot instead of blocking its watcher.
 type clientInfo struct {
 	sync.Mutex
 
-	updateChannel chan<- *v1.WatchResponse
-	finished      bool
+	request    *v1.WatchRequest
+	notify     chan struct{}
+	maxSkipped int
+
+	pending *v1.WatchResponse
+	skipped int
+	lagging bool
+	closed  bool
+}
+
+// newClientInfo creates a mailbox that signals notify, which must be
+// buffered, when it has something to take. Several mailboxes may share the
+// same notify channel.
+func newClientInfo(request *v1.WatchRequest, maxSkipped int, notify chan struct{}) *clientInfo {
+	return &clientInfo{request: request, notify: notify, maxSkipped: maxSkipped}
+}
+
+// offer queues a response for the client, superseding any pending response.
+// It returns false if the client has skipped more than its maximum number of
+// responses and should be disconnected.
+func (c *clientInfo) offer(response *v1.WatchResponse) bool {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.pending != nil {
+		c.skipped++
+		if c.maxSkipped > 0 && c.skipped > c.maxSkipped {
+			c.lagging = true
+		}
+	}
+	c.pending = response
+	c.signal()
+	return !c.lagging
+}
+
+// close marks that no further responses will be offered to the client.
+func (c *clientInfo) close() {
+	c.Lock()
+	defer c.Unlock()
+	c.closed = true
+	c.signal()
+}
+
+// take returns the pending response, if any, along with whether the client
+// fell too far behind or will receive no further responses.
+func (c *clientInfo) take() (response *v1.WatchResponse, lagging, closed bool) {
+	c.Lock()
+	defer c.Unlock()
+	response, c.pending, c.skipped = c.pending, nil, 0
+	return response, c.lagging, c.closed
+}
+
+func (c *clientInfo) signal() {
+	select {
+	case c.notify <- struct{}{}:
+	default:
+	}
 }
 
 type watcher struct {
 	sync.Mutex
 
+	watchKey     string
 	shutdownCtx  context.Context
+	cancel       context.CancelFunc
+	source       sources.Endpoint
+	tuner        sources.Tuner
+	health       *healthTracker
 	clients      []*clientInfo
 	lastResponse *v1.WatchResponse
+	revision     uint64
+	closed       bool
+
+	// sourceErrs receives the failures of sources that report them, and
+	// sourceErr is the latest one, which is cleared once the source recovers.
+	// The endpoints are stale once the source has been failing since
+	// failingSince for longer than staleAfter, when it is set.
+	sourceErrs   <-chan error
+	sourceErr    error
+	failingSince time.Time
+	lastSuccess  time.Time
+	staleAfter   time.Duration
+	stale        deadline
+
+	// Updates that would remove more than panicThreshold percent of the
+	// endpoints are held for up to panicGracePeriod, while the previous
+	// endpoints keep being served. Zero disables the threshold.
+	panicThreshold   float64
+	panicGracePeriod time.Duration
+	held             []*v1.Endpoint
+	heldReason       string
+	release          deadline
+
+	// latest are the endpoints most recently received from the source,
+	// which are published once debounce has passed since the first change
+	// that hasn't been, less any that the dampener withholds until reuse.
+	latest   []*v1.Endpoint
+	debounce time.Duration
+	flush    deadline
+	dampener *flapDampener
+	reuse    deadline
+
+	// prober checks the health of the latest endpoints, when health checking
+	// is enabled, and those that are unhealthy are filtered or annotated.
+	prober *endpointProber
+
+	// history holds up to historySize responses that preceded lastResponse,
+	// oldest first, so that reconnecting clients can resume from them.
+	history     []*v1.WatchResponse
+	historySize int
+}
+
+// deadline is a timer whose channel is nil while it is not running, so that
+// it can be selected on whether or not it has been set.
+type deadline struct {
+	timer *time.Timer
+	C     <-chan time.Time
+}
+
+// reset starts the timer, replacing any that is running.
+func (d *deadline) reset(after time.Duration) {
+	d.stop()
+	d.timer = time.NewTimer(after)
+	d.C = d.timer.C
+}
+
+// stop stops the timer, and must also be called once it has fired.
+func (d *deadline) stop() {
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+	d.timer, d.C = nil, nil
 }
 
 func (w *watcher) run(endpointUpdates sources.Endpoint) {
+	w.Lock()
+	w.reportHealth()
+	w.Unlock()
+	defer func() {
+		w.stale.stop()
+		w.release.stop()
+		w.flush.stop()
+		w.reuse.stop()
+		w.prober.stop()
+	}()
+
 	hadError := false
 	for w.shutdownCtx.Err() == nil && !hadError {
 		select {
+		case err := <-w.sourceErrs:
+			w.Lock()
+			if err != nil && w.sourceErr == nil {
+				log.Warn().Err(err).Str("watchKey", w.watchKey).Msg("endpoint source is failing, serving last known endpoints")
+				w.failingSince = time.Now()
+				if w.staleAfter > 0 {
+					w.stale.reset(w.staleAfter)
+				}
+			}
+			w.sourceErr = err
+			if err == nil {
+				w.stale.stop()
+			}
+			w.publishStatus()
+			w.Unlock()
+		case <-w.stale.C:
+			w.stale.stop()
+			w.Lock()
+			w.publishStatus()
+			w.Unlock()
+		case <-w.release.C:
+			w.release.stop()
+			w.Lock()
+			log.Warn().Str("watchKey", w.watchKey).Int("endpoints", len(w.held)).Msg("releasing held endpoint update after its grace period")
+			held := w.held
+			w.held, w.heldReason = nil, ""
+			w.publish(held)
+			w.Unlock()
+		case <-w.flush.C:
+			w.flush.stop()
+			w.Lock()
+			w.apply()
+			w.Unlock()
+		case <-w.reuse.C:
+			w.reuse.stop()
+			w.Lock()
+			w.apply()
+			w.Unlock()
+		case <-w.prober.changes():
+			w.Lock()
+			w.apply()
+			w.Unlock()
 		case update, ok := <-endpointUpdates:
 			if !ok {
 				log.Error().Msg("unable to read updates from endpoint source")
@@ -36,39 +218,279 @@ func (w *watcher) run(endpointUpdates sources.Endpoint) {
 				break
 			}
 
-			updateResponse := &v1.WatchResponse{Endpoints: update}
-
-			startingClients := len(w.clients)
-			stillAlive := make([]*clientInfo, 0, startingClients)
-
+			// Receiving endpoints means that the source has recovered.
 			w.Lock()
-			w.lastResponse = updateResponse
-			for _, client := range w.clients {
-				client.Lock()
-				if !client.finished {
-					client.updateChannel <- updateResponse
-					stillAlive = append(stillAlive, client)
-				} else {
-					close(client.updateChannel)
-				}
-				client.Unlock()
+			w.sourceErr = nil
+			w.stale.stop()
+			w.lastSuccess = time.Now()
+			if w.shutdownCtx.Err() == nil {
+				watcherLastUpdateGauge.WithLabelValues(w.watchKey).SetToCurrentTime()
 			}
-			w.clients = stillAlive
-			w.Unlock()
+			w.dampener.observe(update, w.lastSuccess)
+			w.prober.update(update)
+			w.latest = update
 
-			prunedClients := startingClients - len(stillAlive)
-			if prunedClients > 0 {
-				log.Info().Int("pruned", prunedClients).Msg("pruned finished clients")
+			// Changes are coalesced over the debounce window, except for
+			// the first endpoints, which clients are waiting for.
+			resolved := w.lastResponse != nil && w.lastResponse.SourceStatus != v1.WatchResponse_SOURCE_STATUS_ERROR
+			if w.debounce > 0 && resolved {
+				if w.flush.C == nil {
+					w.flush.reset(w.debounce)
+				}
+			} else {
+				w.apply()
 			}
+			w.Unlock()
 		case <-w.shutdownCtx.Done():
 			log.Info().Msg("shutting down watcher")
 		}
 	}
 
-	log.Info().Msg("closing client update channels")
+	log.Info().Msg("closing client mailboxes")
+	w.Lock()
+	defer w.Unlock()
+	w.closed = true
+	w.reportHealth()
+	for _, client := range w.clients {
+		client.close()
+	}
+}
+
+// apply publishes the latest endpoints from the source, without those that
+// are being dampened and with the health that probes determined, unless they
+// exceed the panic threshold. The watcher must be locked.
+func (w *watcher) apply() {
+	w.flush.stop()
+
+	endpoints, nextReuse := w.dampener.filter(w.latest, time.Now())
+	w.reuse.stop()
+	if !nextReuse.IsZero() {
+		w.reuse.reset(time.Until(nextReuse))
+	}
+	suppressed := len(w.latest) - len(endpoints)
+	endpoints, unhealthy := w.prober.apply(endpoints)
+	if w.shutdownCtx.Err() == nil {
+		targetSuppressedEndpointsGauge.WithLabelValues(w.watchKey).Set(float64(suppressed))
+		targetUnhealthyEndpointsGauge.WithLabelValues(w.watchKey).Set(float64(unhealthy))
+	}
+
+	var previous []*v1.Endpoint
+	if w.lastResponse != nil {
+		previous = w.lastResponse.Endpoints
+	}
+	if reason := panicReason(previous, endpoints, w.panicThreshold); reason != "" {
+		if w.held == nil {
+			log.Warn().Str("watchKey", w.watchKey).Str("reason", reason).Msg("holding endpoint update that exceeds the panic threshold")
+			if w.panicGracePeriod > 0 {
+				w.release.reset(w.panicGracePeriod)
+			}
+		}
+		if w.shutdownCtx.Err() == nil {
+			watcherHeldUpdatesCounter.WithLabelValues(w.watchKey).Inc()
+		}
+		w.held, w.heldReason = endpoints, reason
+		w.publishStatus()
+		return
+	}
+
+	w.release.stop()
+	w.held, w.heldReason = nil, ""
+	if w.lastResponse != nil && w.lastResponse.SourceStatus == v1.WatchResponse_SOURCE_STATUS_OK && endpointsEqual(w.lastResponse.Endpoints, endpoints) {
+		return
+	}
+	w.publish(endpoints)
+}
+
+// endpointsEqual returns whether the endpoints are the same, in order.
+func endpointsEqual(a, b []*v1.Endpoint) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !proto.Equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// status describes whether the watcher's endpoints are current, along with
+// the source's error when they are not. The watcher must be locked.
+func (w *watcher) status() (v1.WatchResponse_SourceStatus, string) {
+	switch {
+	case w.sourceErr == nil && w.held != nil:
+		return v1.WatchResponse_SOURCE_STATUS_DEGRADED, w.heldReason
+	case w.sourceErr == nil:
+		return v1.WatchResponse_SOURCE_STATUS_OK, ""
+	case w.lastSuccess.IsZero():
+		return v1.WatchResponse_SOURCE_STATUS_ERROR, w.sourceErr.Error()
+	case w.staleAfter > 0 && time.Since(w.failingSince) >= w.staleAfter:
+		return v1.WatchResponse_SOURCE_STATUS_STALE, w.sourceErr.Error()
+	default:
+		return v1.WatchResponse_SOURCE_STATUS_DEGRADED, w.sourceErr.Error()
+	}
+}
+
+// panicReason describes why the next endpoints should be held rather than
+// replace the previous ones, or returns an empty string if they shouldn't be.
+// They are held when they would remove every previous endpoint, or more than
+// threshold percent of them. A threshold of zero never holds them.
+func panicReason(previous, next []*v1.Endpoint, threshold float64) string {
+	if threshold <= 0 || len(previous) == 0 {
+		return ""
+	}
+	if len(next) == 0 {
+		return fmt.Sprintf("update would remove all %d endpoints", len(previous))
+	}
+
+	remaining := make(map[string]struct{}, len(next))
+	for _, endpoint := range next {
+		remaining[endpointKey(endpoint)] = struct{}{}
+	}
+	removed := 0
+	for _, endpoint := range previous {
+		if _, ok := remaining[endpointKey(endpoint)]; !ok {
+			removed++
+		}
+	}
+
+	if percent := 100 * float64(removed) / float64(len(previous)); percent > threshold {
+		return fmt.Sprintf("update would remove %d of %d endpoints (%.0f%%)", removed, len(previous), percent)
+	}
+	return ""
+}
+
+// publishStatus sends the last known endpoints again if their status has
+// changed. The watcher must be locked.
+func (w *watcher) publishStatus() {
+	status, sourceErr := w.status()
+	if w.lastResponse == nil && status == v1.WatchResponse_SOURCE_STATUS_OK {
+		w.reportHealth()
+		return
+	}
+	if w.lastResponse != nil && w.lastResponse.SourceStatus == status && w.lastResponse.SourceError == sourceErr {
+		w.reportHealth()
+		return
+	}
+
+	var endpoints []*v1.Endpoint
+	if w.lastResponse != nil {
+		endpoints = w.lastResponse.Endpoints
+	}
+	w.publish(endpoints)
+}
+
+// publish sends the endpoints to every client as a new revision, along with
+// their current status. The watcher must be locked.
+func (w *watcher) publish(endpoints []*v1.Endpoint) {
+	w.revision++
+	response := &v1.WatchResponse{Endpoints: endpoints, Revision: w.revision}
+	response.SourceStatus, response.SourceError = w.status()
+	if !w.lastSuccess.IsZero() {
+		response.LastSuccessfulUpdate = timestamppb.New(w.lastSuccess)
+	}
+
+	if w.lastResponse != nil && w.historySize > 0 {
+		if len(w.history) == w.historySize {
+			w.history = w.history[1:]
+		}
+		w.history = append(w.history, w.lastResponse)
+	}
+	w.lastResponse = response
+
+	startingClients := len(w.clients)
+	keptUp := make([]*clientInfo, 0, startingClients)
+	for _, client := range w.clients {
+		if client.offer(response) {
+			keptUp = append(keptUp, client)
+		}
+	}
+	w.clients = keptUp
+
+	if w.shutdownCtx.Err() == nil {
+		targetEndpointsGauge.WithLabelValues(w.watchKey).Set(float64(len(endpoints)))
+		watcherUpdatesCounter.WithLabelValues(w.watchKey).Inc()
+		w.recordClients()
+	}
+	w.reportHealth()
+
+	if lagging := startingClients - len(keptUp); lagging > 0 {
+		prunedClientsCounter.Add(float64(lagging))
+		log.Warn().Str("watchKey", w.watchKey).Int("lagging", lagging).Msg("disconnecting clients that fell too far behind")
+	}
+}
+
+// reportHealth reports whether the watcher is serving endpoints from a
+// healthy source, unless it has been evicted. The watcher must be locked.
+func (w *watcher) reportHealth() {
+	if w.shutdownCtx.Err() != nil {
+		return
+	}
+	serving := !w.closed && w.sourceErr == nil && w.lastResponse != nil && len(w.lastResponse.Endpoints) > 0
+	w.health.set(w.watchKey, serving)
+}
+
+// addClient starts sending updates to the client, beginning with the last
+// response if one has been received and the client has not already seen it.
+// It returns the response for the revision the client last saw, if it is
+// still known. The watcher must be locked.
+func (w *watcher) addClient(client *clientInfo) (seen *v1.WatchResponse) {
+	if revision := client.request.LastSeenRevision; revision != 0 {
+		if w.lastResponse != nil && w.lastResponse.Revision == revision {
+			seen = w.lastResponse
+		}
+		for _, response := range w.history {
+			if response.Revision == revision {
+				seen = response
+			}
+		}
+	}
+
+	if w.lastResponse != nil && seen != w.lastResponse {
+		client.offer(w.lastResponse)
+	}
+	w.clients = append(w.clients, client)
+	w.tune()
+	w.recordClients()
+	return seen
+}
+
+// removeClient stops sending updates to the client and returns the number of
+// clients that remain.
+func (w *watcher) removeClient(client *clientInfo) int {
+	w.Lock()
+	defer w.Unlock()
+
+	for i, existing := range w.clients {
+		if existing == client {
+			w.clients = append(w.clients[:i], w.clients[i+1:]...)
+			break
+		}
+	}
+	w.tune()
+	w.recordClients()
+	return len(w.clients)
+}
+
+// recordClients updates the metric of how many clients the watcher has,
+// unless it has been evicted and its metrics deleted. The watcher must be
+// locked.
+func (w *watcher) recordClients() {
+	if w.shutdownCtx.Err() == nil {
+		watcherClientsGauge.WithLabelValues(w.watchKey).Set(float64(len(w.clients)))
+	}
+}
+
+// tune adapts the source to the requests of the current clients, if it
+// supports doing so. The watcher must be locked.
+func (w *watcher) tune() {
+	if w.tuner == nil {
+		return
+	}
+
+	requests := make([]*v1.WatchRequest, 0, len(w.clients))
 	for _, client := range w.clients {
-		client.Lock()
-		close(client.updateChannel)
-		client.Unlock()
+		requests = append(requests, client.request)
 	}
+	w.tuner.Tune(w.source, requests)
 }
@@ -2,9 +2,11 @@ package services
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
 
 	v1 "github.com/authzed/servok/internal/proto/servok/api/v1"
@@ -37,11 +39,11 @@ func TestMultiplexingAndCleanup(t *testing.T) {
 				shutdownCtx: ctx,
 			}
 
-			var clientChans []chan *v1.WatchResponse
+			var clients []*clientInfo
 			for i := 0; i < 5; i++ {
-				clientChan := make(chan *v1.WatchResponse)
-				clientChans = append(clientChans, clientChan)
-				watcher.clients = append(watcher.clients, &clientInfo{updateChannel: clientChan})
+				client := newClientInfo(nil, 0, make(chan struct{}, 1))
+				clients = append(clients, client)
+				watcher.clients = append(watcher.clients, client)
 			}
 
 			exited := false
@@ -62,16 +64,17 @@ func TestMultiplexingAndCleanup(t *testing.T) {
 				},
 			}
 
-			for _, cc := range clientChans {
+			for _, client := range clients {
 				require.Eventually(func() bool {
 					select {
-					case update, ok := <-cc:
-						if ok {
-							require.Equal("test", update.Endpoints[0].Hostname)
-							require.Equal(uint32(50051), update.Endpoints[0].Port)
-							require.Equal(uint32(1), update.Endpoints[0].Weight)
-							return true
-						}
+					case <-client.notify:
+						update, lagging, closed := client.take()
+						require.False(lagging)
+						require.False(closed)
+						require.Equal("test", update.Endpoints[0].Hostname)
+						require.Equal(uint32(50051), update.Endpoints[0].Port)
+						require.Equal(uint32(1), update.Endpoints[0].Weight)
+						return true
 					default:
 					}
 					return false
@@ -85,13 +88,99 @@ func TestMultiplexingAndCleanup(t *testing.T) {
 				return exited
 			}, 100*time.Millisecond, 1*time.Millisecond)
 
-			for _, cc := range clientChans {
+			for _, client := range clients {
 				select {
-				case <-cc:
+				case <-client.notify:
+					_, _, closed := client.take()
+					require.True(closed)
 				default:
-					require.Fail("all channels should be closed")
+					require.Fail("all clients should be closed")
 				}
 			}
 		})
 	}
 }
+
+func TestSlowClients(t *testing.T) {
+	require := require.New(t)
+
+	updateChan := make(chan []*v1.Endpoint)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	watcher := &watcher{shutdownCtx: ctx}
+	fast := newClientInfo(nil, 0, make(chan struct{}, 1))
+	slow := newClientInfo(nil, 0, make(chan struct{}, 1))
+	lagging := newClientInfo(nil, 2, make(chan struct{}, 1))
+	watcher.clients = []*clientInfo{fast, slow, lagging}
+
+	pruned := testutil.ToFloat64(prunedClientsCounter)
+	go watcher.run(updateChan)
+
+	// None of the clients are reading, yet the watcher is never blocked.
+	for i := 0; i < 3; i++ {
+		updateChan <- []*v1.Endpoint{{Hostname: fmt.Sprintf("host%d", i), Port: 50051, Weight: 1}}
+	}
+
+	// Clients only receive the latest update.
+	<-fast.notify
+	update, isLagging, closed := fast.take()
+	require.False(isLagging)
+	require.False(closed)
+	require.Equal("host2", update.Endpoints[0].Hostname)
+
+	updateChan <- []*v1.Endpoint{{Hostname: "host3", Port: 50051, Weight: 1}}
+	<-fast.notify
+	update, _, _ = fast.take()
+	require.Equal("host3", update.Endpoints[0].Hostname)
+
+	<-slow.notify
+	update, isLagging, _ = slow.take()
+	require.False(isLagging)
+	require.Equal("host3", update.Endpoints[0].Hostname)
+
+	// Clients that skip too many updates are disconnected.
+	<-lagging.notify
+	_, isLagging, _ = lagging.take()
+	require.True(isLagging)
+
+	require.Eventually(func() bool {
+		return testutil.ToFloat64(prunedClientsCounter) == pruned+1
+	}, 1*time.Second, 1*time.Millisecond)
+
+	watcher.Lock()
+	defer watcher.Unlock()
+	require.Equal([]*clientInfo{fast, slow}, watcher.clients)
+}
+
+func TestPanicReason(t *testing.T) {
+	hosts := func(names ...string) []*v1.Endpoint {
+		endpoints := make([]*v1.Endpoint, 0, len(names))
+		for _, name := range names {
+			endpoints = append(endpoints, &v1.Endpoint{Hostname: name, Port: 50051})
+		}
+		return endpoints
+	}
+
+	testCases := []struct {
+		name      string
+		previous  []*v1.Endpoint
+		next      []*v1.Endpoint
+		threshold float64
+		expected  string
+	}{
+		{"disabled", hosts("a", "b"), hosts(), 0, ""},
+		{"first update", hosts(), hosts("a"), 50, ""},
+		{"all removed", hosts("a", "b"), hosts(), 50, "update would remove all 2 endpoints"},
+		{"within threshold", hosts("a", "b", "c", "d"), hosts("a", "b", "e"), 50, ""},
+		{"at threshold", hosts("a", "b", "c", "d"), hosts("a", "b"), 50, ""},
+		{"over threshold", hosts("a", "b", "c", "d"), hosts("a", "e", "f", "g"), 50, "update would remove 3 of 4 endpoints (75%)"},
+		{"additions", hosts("a"), hosts("a", "b", "c"), 1, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, panicReason(tc.previous, tc.next, tc.threshold))
+		})
+	}
+}
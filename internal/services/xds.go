@@ -0,0 +1,412 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	clusterv3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	endpointv3 "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	listenerv3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	routerv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/router/v3"
+	hcmv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	discoveryv3 "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	cachev3 "github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+	resourcev3 "github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	serverv3 "github.com/envoyproxy/go-control-plane/pkg/server/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	v1 "github.com/authzed/servok/internal/proto/servok/api/v1"
+	"github.com/authzed/servok/internal/sources"
+)
+
+// xdsResubscribeDelay is how long the xDS server waits before watching a
+// target again after its watch ended, such as when its source closed.
+const xdsResubscribeDelay = 1 * time.Second
+
+// NewXDSServer creates an xDS server that serves the endpoints watched by the
+// servicer, which must have been created by NewEndpointServicer.
+//
+// Resources are named by their target, as in sources.ParseTarget, and every
+// target is served as a listener and a route for gRPC's xds resolver, a
+// cluster, and a ClusterLoadAssignment. Targets are watched, sharing the
+// servicer's watchers, for as long as any xDS stream requests them.
+//
+// Both state-of-the-world and incremental (delta) xDS streams are served.
+// Requested load assignments are empty until the target's endpoints resolve.
+//
+// Endpoint priorities are mapped to locality priorities and weights to
+// endpoint weights, where weights of zero are sent as one, the lowest that
+// xDS allows. Endpoints are addressed by their IP addresses when resolved,
+// and otherwise by hostname, which Envoy does not resolve for EDS clusters.
+func NewXDSServer(ctx context.Context, servicer v1.EndpointServiceServer) (serverv3.Server, error) {
+	es, ok := servicer.(*endpointServicer)
+	if !ok {
+		return nil, errors.New("xDS server requires a servicer created by NewEndpointServicer")
+	}
+
+	xs := &xdsServer{
+		ctx:          ctx,
+		es:           es,
+		listeners:    cachev3.NewLinearCache(resourcev3.ListenerType),
+		clusters:     cachev3.NewLinearCache(resourcev3.ClusterType),
+		assignments:  cachev3.NewLinearCache(resourcev3.EndpointType),
+		streams:      map[int64]map[string]map[string]struct{}{},
+		deltaStreams: map[int64]map[string]map[string]struct{}{},
+		targets:      map[string]*xdsTarget{},
+	}
+
+	cache := &cachev3.MuxCache{
+		Classify: func(request *cachev3.Request) string {
+			return request.TypeUrl
+		},
+		ClassifyDelta: func(request *cachev3.DeltaRequest) string {
+			return request.TypeUrl
+		},
+		Caches: map[string]cachev3.Cache{
+			resourcev3.ListenerType: xs.listeners,
+			resourcev3.ClusterType:  xs.clusters,
+			resourcev3.EndpointType: xs.assignments,
+		},
+	}
+
+	return serverv3.NewServer(ctx, cache, serverv3.CallbackFuncs{
+		StreamRequestFunc:      xs.onStreamRequest,
+		StreamClosedFunc:       xs.onStreamClosed,
+		StreamDeltaRequestFunc: xs.onStreamDeltaRequest,
+		DeltaStreamClosedFunc:  xs.onDeltaStreamClosed,
+	}), nil
+}
+
+type xdsServer struct {
+	sync.Mutex
+
+	ctx         context.Context
+	es          *endpointServicer
+	listeners   *cachev3.LinearCache
+	clusters    *cachev3.LinearCache
+	assignments *cachev3.LinearCache
+
+	// streams and deltaStreams hold the resource names requested by each
+	// stream by type. They are kept apart because the two kinds of stream
+	// are numbered independently.
+	streams      map[int64]map[string]map[string]struct{}
+	deltaStreams map[int64]map[string]map[string]struct{}
+	targets      map[string]*xdsTarget
+}
+
+// xdsTarget is a target requested by at least one xDS stream.
+type xdsTarget struct {
+	streams int
+	cancel  context.CancelFunc
+}
+
+func (xs *xdsServer) onStreamRequest(streamID int64, request *discoveryv3.DiscoveryRequest) error {
+	xs.Lock()
+	defer xs.Unlock()
+
+	names := map[string]struct{}{}
+	for _, name := range request.ResourceNames {
+		names[name] = struct{}{}
+	}
+	xs.request(xs.streams, streamID, request.TypeUrl, names)
+	return nil
+}
+
+func (xs *xdsServer) onStreamClosed(streamID int64) {
+	xs.Lock()
+	defer xs.Unlock()
+
+	xs.close(xs.streams, streamID)
+}
+
+// onStreamDeltaRequest applies the changes to the names that the delta stream
+// subscribes to, which on a reconnect include those it already has versions
+// of.
+func (xs *xdsServer) onStreamDeltaRequest(streamID int64, request *discoveryv3.DeltaDiscoveryRequest) error {
+	xs.Lock()
+	defer xs.Unlock()
+
+	names := map[string]struct{}{}
+	for name := range xs.deltaStreams[streamID][request.TypeUrl] {
+		names[name] = struct{}{}
+	}
+	for name := range request.InitialResourceVersions {
+		names[name] = struct{}{}
+	}
+	for _, name := range request.ResourceNamesSubscribe {
+		names[name] = struct{}{}
+	}
+	for _, name := range request.ResourceNamesUnsubscribe {
+		delete(names, name)
+	}
+	xs.request(xs.deltaStreams, streamID, request.TypeUrl, names)
+	return nil
+}
+
+func (xs *xdsServer) onDeltaStreamClosed(streamID int64) {
+	xs.Lock()
+	defer xs.Unlock()
+
+	xs.close(xs.deltaStreams, streamID)
+}
+
+// request replaces the names of the type requested by the stream, acquiring
+// the targets it newly requests and releasing those it no longer does. The
+// server must be locked.
+func (xs *xdsServer) request(streams map[int64]map[string]map[string]struct{}, streamID int64, typeURL string, names map[string]struct{}) {
+	before := streamTargets(streams, streamID)
+
+	byType, ok := streams[streamID]
+	if !ok {
+		byType = map[string]map[string]struct{}{}
+		streams[streamID] = byType
+	}
+	byType[typeURL] = names
+
+	after := streamTargets(streams, streamID)
+	for name := range after {
+		if _, ok := before[name]; !ok {
+			xs.acquire(name)
+		}
+	}
+	for name := range before {
+		if _, ok := after[name]; !ok {
+			xs.release(name)
+		}
+	}
+}
+
+// close releases the targets requested by the stream. The server must be
+// locked.
+func (xs *xdsServer) close(streams map[int64]map[string]map[string]struct{}, streamID int64) {
+	for name := range streamTargets(streams, streamID) {
+		xs.release(name)
+	}
+	delete(streams, streamID)
+}
+
+// streamTargets returns the names requested by the stream across all types.
+func streamTargets(streams map[int64]map[string]map[string]struct{}, streamID int64) map[string]struct{} {
+	targets := map[string]struct{}{}
+	for _, names := range streams[streamID] {
+		for name := range names {
+			targets[name] = struct{}{}
+		}
+	}
+	return targets
+}
+
+// acquire starts serving the target, if it is not already being served. The
+// server must be locked.
+func (xs *xdsServer) acquire(name string) {
+	if target, ok := xs.targets[name]; ok {
+		target.streams++
+		return
+	}
+
+	request, err := sources.ParseTarget(name)
+	if err == nil {
+		err = request.Validate()
+	}
+	if err != nil {
+		log.Info().Err(err).Str("name", name).Msg("ignoring xDS request for invalid target")
+		return
+	}
+
+	if err := xs.listeners.UpdateResource(name, xdsListener(name)); err != nil {
+		log.Error().Err(err).Str("name", name).Msg("unable to serve xDS listener")
+	}
+	if err := xs.clusters.UpdateResource(name, xdsCluster(name)); err != nil {
+		log.Error().Err(err).Str("name", name).Msg("unable to serve xDS cluster")
+	}
+	// Delta streams drop names that are missing from the cache when they
+	// subscribe, so the load assignment is served empty until it resolves.
+	if err := xs.assignments.UpdateResource(name, xdsLoadAssignment(name, nil)); err != nil {
+		log.Error().Err(err).Str("name", name).Msg("unable to serve xDS endpoints")
+	}
+
+	ctx, cancel := context.WithCancel(xs.ctx)
+	xs.targets[name] = &xdsTarget{streams: 1, cancel: cancel}
+	go xs.watch(ctx, name, request)
+}
+
+// release stops serving the target once no streams request it. The server
+// must be locked.
+func (xs *xdsServer) release(name string) {
+	target, ok := xs.targets[name]
+	if !ok {
+		return
+	}
+
+	target.streams--
+	if target.streams > 0 {
+		return
+	}
+
+	target.cancel()
+	delete(xs.targets, name)
+	for _, cache := range []*cachev3.LinearCache{xs.listeners, xs.clusters, xs.assignments} {
+		_ = cache.DeleteResource(name)
+	}
+}
+
+// watch serves the endpoints of the target as a ClusterLoadAssignment until
+// the context is canceled, watching it again whenever its watch ends.
+func (xs *xdsServer) watch(ctx context.Context, name string, request *v1.WatchRequest) {
+	for ctx.Err() == nil {
+		notify := make(chan struct{}, 1)
+		sub, err := xs.es.subscribe(request, notify)
+		if err != nil {
+			log.Warn().Err(err).Str("name", name).Msg("unable to watch xDS target")
+		} else {
+			xs.forward(ctx, name, sub, notify)
+			xs.es.unsubscribe(sub)
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(xdsResubscribeDelay):
+		}
+	}
+}
+
+func (xs *xdsServer) forward(ctx context.Context, name string, sub *subscription, notify <-chan struct{}) {
+	for {
+		select {
+		case <-notify:
+			response, err := sub.next()
+			if response != nil && response.SourceStatus != v1.WatchResponse_SOURCE_STATUS_ERROR {
+				if err := xs.assignments.UpdateResource(name, xdsLoadAssignment(name, response.Endpoints)); err != nil {
+					log.Error().Err(err).Str("name", name).Msg("unable to serve xDS endpoints")
+				}
+			}
+			if err != nil {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func xdsListener(name string) *listenerv3.Listener {
+	router, _ := anypb.New(&routerv3.Router{})
+	manager, _ := anypb.New(&hcmv3.HttpConnectionManager{
+		RouteSpecifier: &hcmv3.HttpConnectionManager_RouteConfig{
+			RouteConfig: &routev3.RouteConfiguration{
+				Name: name,
+				VirtualHosts: []*routev3.VirtualHost{{
+					Name:    name,
+					Domains: []string{"*"},
+					Routes: []*routev3.Route{{
+						Match: &routev3.RouteMatch{PathSpecifier: &routev3.RouteMatch_Prefix{Prefix: "/"}},
+						Action: &routev3.Route_Route{Route: &routev3.RouteAction{
+							ClusterSpecifier: &routev3.RouteAction_Cluster{Cluster: name},
+						}},
+					}},
+				}},
+			},
+		},
+		HttpFilters: []*hcmv3.HttpFilter{{
+			Name:       wellknown.Router,
+			ConfigType: &hcmv3.HttpFilter_TypedConfig{TypedConfig: router},
+		}},
+	})
+
+	return &listenerv3.Listener{
+		Name:        name,
+		ApiListener: &listenerv3.ApiListener{ApiListener: manager},
+	}
+}
+
+func xdsCluster(name string) *clusterv3.Cluster {
+	return &clusterv3.Cluster{
+		Name:                 name,
+		ClusterDiscoveryType: &clusterv3.Cluster_Type{Type: clusterv3.Cluster_EDS},
+		EdsClusterConfig: &clusterv3.Cluster_EdsClusterConfig{
+			EdsConfig: &corev3.ConfigSource{
+				ConfigSourceSpecifier: &corev3.ConfigSource_Ads{Ads: &corev3.AggregatedConfigSource{}},
+				ResourceApiVersion:    corev3.ApiVersion_V3,
+			},
+		},
+		LbPolicy: clusterv3.Cluster_ROUND_ROBIN,
+	}
+}
+
+// xdsLoadAssignment groups the endpoints into a locality for each of their
+// priorities, which xDS requires to be numbered consecutively from zero.
+func xdsLoadAssignment(name string, endpoints []*v1.Endpoint) *endpointv3.ClusterLoadAssignment {
+	byPriority := map[uint32][]*endpointv3.LbEndpoint{}
+	for _, endpoint := range endpoints {
+		byPriority[endpoint.Priority] = append(byPriority[endpoint.Priority], xdsLbEndpoints(endpoint)...)
+	}
+
+	priorities := make([]uint32, 0, len(byPriority))
+	for priority := range byPriority {
+		priorities = append(priorities, priority)
+	}
+	sort.Slice(priorities, func(i, j int) bool { return priorities[i] < priorities[j] })
+
+	assignment := &endpointv3.ClusterLoadAssignment{ClusterName: name}
+	for i, priority := range priorities {
+		assignment.Endpoints = append(assignment.Endpoints, &endpointv3.LocalityLbEndpoints{
+			Locality:            &corev3.Locality{SubZone: "priority-" + strconv.FormatUint(uint64(priority), 10)},
+			LbEndpoints:         byPriority[priority],
+			LoadBalancingWeight: wrapperspb.UInt32(1),
+			Priority:            uint32(i),
+		})
+	}
+	return assignment
+}
+
+func xdsLbEndpoints(endpoint *v1.Endpoint) []*endpointv3.LbEndpoint {
+	hosts := endpoint.Addresses
+	if len(hosts) == 0 {
+		hosts = []string{strings.TrimSuffix(endpoint.Hostname, ".")}
+	}
+
+	weight := endpoint.Weight
+	if weight == 0 {
+		weight = 1
+	}
+
+	health := corev3.HealthStatus_UNKNOWN
+	if conditions := endpoint.Conditions; conditions != nil {
+		switch {
+		case conditions.Terminating:
+			health = corev3.HealthStatus_DRAINING
+		case conditions.Ready:
+			health = corev3.HealthStatus_HEALTHY
+		case conditions.Serving:
+			health = corev3.HealthStatus_DRAINING
+		default:
+			health = corev3.HealthStatus_UNHEALTHY
+		}
+	}
+
+	lbEndpoints := make([]*endpointv3.LbEndpoint, 0, len(hosts))
+	for _, host := range hosts {
+		lbEndpoints = append(lbEndpoints, &endpointv3.LbEndpoint{
+			HostIdentifier: &endpointv3.LbEndpoint_Endpoint{Endpoint: &endpointv3.Endpoint{
+				Address: &corev3.Address{Address: &corev3.Address_SocketAddress{SocketAddress: &corev3.SocketAddress{
+					Address:       host,
+					PortSpecifier: &corev3.SocketAddress_PortValue{PortValue: endpoint.Port},
+				}}},
+				Hostname: strings.TrimSuffix(endpoint.Hostname, "."),
+			}},
+			HealthStatus:        health,
+			LoadBalancingWeight: wrapperspb.UInt32(weight),
+		})
+	}
+	return lbEndpoints
+}
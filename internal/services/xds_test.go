@@ -0,0 +1,272 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	endpointv3 "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	listenerv3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	discoverygrpc "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	resourcev3 "github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	v1 "github.com/authzed/servok/internal/proto/servok/api/v1"
+	"github.com/authzed/servok/internal/services/servicestest"
+	"github.com/authzed/servok/internal/sources"
+)
+
+func TestXDSLoadAssignment(t *testing.T) {
+	assignment := xdsLoadAssignment("srv/grpc/tcp/example.com", []*v1.Endpoint{
+		{Hostname: "host1.example.com.", Port: 50051, Weight: 5, Priority: 20, Addresses: []string{"10.0.0.1", "10.0.0.2"}},
+		{Hostname: "host2.example.com.", Port: 50051, Weight: 0, Priority: 10, Conditions: &v1.Endpoint_Conditions{Ready: true}},
+		{Hostname: "host3.example.com.", Port: 50051, Weight: 2, Priority: 10, Conditions: &v1.Endpoint_Conditions{Serving: true}},
+	})
+
+	type lbEndpoint struct {
+		address  string
+		weight   uint32
+		priority uint32
+		health   corev3.HealthStatus
+	}
+	var endpoints []lbEndpoint
+	for _, locality := range assignment.Endpoints {
+		for _, endpoint := range locality.LbEndpoints {
+			endpoints = append(endpoints, lbEndpoint{
+				endpoint.GetEndpoint().Address.GetSocketAddress().Address,
+				endpoint.LoadBalancingWeight.Value,
+				locality.Priority,
+				endpoint.HealthStatus,
+			})
+		}
+	}
+
+	require.Equal(t, []lbEndpoint{
+		{"host2.example.com", 1, 0, corev3.HealthStatus_HEALTHY},
+		{"host3.example.com", 2, 0, corev3.HealthStatus_DRAINING},
+		{"10.0.0.1", 5, 1, corev3.HealthStatus_UNKNOWN},
+		{"10.0.0.2", 5, 1, corev3.HealthStatus_UNKNOWN},
+	}, endpoints)
+}
+
+func TestXDSServer(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	kind := &servicestest.Kind{}
+	conn := servicestest.Dial(t, kind, registerXDS)
+
+	streamCtx, closeStream := context.WithCancel(ctx)
+	stream, err := discoverygrpc.NewAggregatedDiscoveryServiceClient(conn).StreamAggregatedResources(streamCtx)
+	require.NoError(err)
+
+	const name = "srv/grpc/tcp/example.com"
+	responses := make(chan *discoverygrpc.DiscoveryResponse)
+	go func() {
+		for {
+			resp, err := stream.Recv()
+			if err != nil {
+				close(responses)
+				return
+			}
+			responses <- resp
+		}
+	}()
+	nextResponse := func() *discoverygrpc.DiscoveryResponse {
+		select {
+		case resp, ok := <-responses:
+			require.True(ok, "stream closed")
+			return resp
+		case <-time.After(1 * time.Second):
+			require.FailNow("timed out waiting for response")
+			return nil
+		}
+	}
+
+	// The listener and cluster are served as soon as they are requested
+	for _, typeURL := range []string{resourcev3.ListenerType, resourcev3.ClusterType} {
+		require.NoError(stream.Send(&discoverygrpc.DiscoveryRequest{TypeUrl: typeURL, ResourceNames: []string{name}}))
+		resp := nextResponse()
+		require.Equal(typeURL, resp.TypeUrl)
+		require.Len(resp.Resources, 1)
+	}
+
+	ack := func(resp *discoverygrpc.DiscoveryResponse) {
+		require.NoError(stream.Send(&discoverygrpc.DiscoveryRequest{
+			TypeUrl:       resourcev3.EndpointType,
+			ResourceNames: []string{name},
+			VersionInfo:   resp.VersionInfo,
+			ResponseNonce: resp.Nonce,
+		}))
+	}
+
+	expectEndpoints := func(expected map[string]uint32) *discoverygrpc.DiscoveryResponse {
+		resp := nextResponse()
+		require.Equal(resourcev3.EndpointType, resp.TypeUrl)
+		require.Len(resp.Resources, 1)
+		require.Equal(expected, xdsWeights(t, name, resp.Resources[0]))
+		return resp
+	}
+
+	// Until the target has endpoints its load assignment is empty
+	require.NoError(stream.Send(&discoverygrpc.DiscoveryRequest{TypeUrl: resourcev3.EndpointType, ResourceNames: []string{name}}))
+	ack(expectEndpoints(map[string]uint32{}))
+
+	require.Eventually(func() bool { return len(kind.Sources()) == 1 }, 1*time.Second, 1*time.Millisecond)
+	source := kind.Sources()[0]
+
+	source.Updates <- []*v1.Endpoint{
+		{Hostname: "host1", Port: 50051, Weight: 1},
+		{Hostname: "host2", Port: 50051, Weight: 3},
+	}
+	ack(expectEndpoints(map[string]uint32{"host1": 1, "host2": 3}))
+	source.Updates <- []*v1.Endpoint{{Hostname: "host1", Port: 50051, Weight: 2}}
+	expectEndpoints(map[string]uint32{"host1": 2})
+
+	// Closing the stream releases the target and its watch
+	closeStream()
+	require.Eventually(func() bool {
+		select {
+		case <-source.Ctx.Done():
+			return true
+		default:
+			return false
+		}
+	}, 1*time.Second, 1*time.Millisecond)
+}
+
+func TestXDSServerDelta(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	kind := &servicestest.Kind{}
+	conn := servicestest.Dial(t, kind, registerXDS)
+
+	stream, err := discoverygrpc.NewAggregatedDiscoveryServiceClient(conn).DeltaAggregatedResources(ctx)
+	require.NoError(err)
+
+	const name = "srv/grpc/tcp/example.com"
+	responses := make(chan *discoverygrpc.DeltaDiscoveryResponse)
+	go func() {
+		for {
+			resp, err := stream.Recv()
+			if err != nil {
+				close(responses)
+				return
+			}
+			responses <- resp
+		}
+	}()
+	expectEndpoints := func(expected map[string]uint32) {
+		select {
+		case resp, ok := <-responses:
+			require.True(ok, "stream closed")
+			require.Equal(resourcev3.EndpointType, resp.TypeUrl)
+			require.Len(resp.Resources, 1)
+			require.Equal(name, resp.Resources[0].Name)
+			require.Equal(expected, xdsWeights(t, name, resp.Resources[0].Resource))
+			require.NoError(stream.Send(&discoverygrpc.DeltaDiscoveryRequest{TypeUrl: resourcev3.EndpointType, ResponseNonce: resp.Nonce}))
+		case <-time.After(1 * time.Second):
+			require.FailNow("timed out waiting for response")
+		}
+	}
+
+	// Subscribing serves the empty load assignment until the target resolves
+	require.NoError(stream.Send(&discoverygrpc.DeltaDiscoveryRequest{
+		TypeUrl:                resourcev3.EndpointType,
+		ResourceNamesSubscribe: []string{name},
+	}))
+	expectEndpoints(map[string]uint32{})
+
+	require.Eventually(func() bool { return len(kind.Sources()) == 1 }, 1*time.Second, 1*time.Millisecond)
+	source := kind.Sources()[0]
+
+	source.Updates <- []*v1.Endpoint{{Hostname: "host1", Port: 50051, Weight: 2}}
+	expectEndpoints(map[string]uint32{"host1": 2})
+
+	// Unsubscribing releases the target and its watch
+	require.NoError(stream.Send(&discoverygrpc.DeltaDiscoveryRequest{
+		TypeUrl:                  resourcev3.EndpointType,
+		ResourceNamesUnsubscribe: []string{name},
+	}))
+	require.Eventually(func() bool {
+		select {
+		case <-source.Ctx.Done():
+			return true
+		default:
+			return false
+		}
+	}, 1*time.Second, 1*time.Millisecond)
+}
+
+func TestXDSServerIgnoresInvalidTargets(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	kind := &servicestest.Kind{}
+	conn := servicestest.Dial(t, kind, registerXDS)
+
+	stream, err := discoverygrpc.NewAggregatedDiscoveryServiceClient(conn).StreamAggregatedResources(ctx)
+	require.NoError(err)
+
+	const valid = "srv/grpc/tcp/example.com"
+	require.NoError(stream.Send(&discoverygrpc.DiscoveryRequest{
+		TypeUrl: resourcev3.ListenerType,
+		ResourceNames: []string{
+			valid,
+			"srv/grpc/sctp/example.com",
+			"srv/grpc/tcp/Example.com",
+			"unknown/example.com",
+		},
+	}))
+	resp, err := stream.Recv()
+	require.NoError(err)
+
+	var names []string
+	for _, resource := range resp.Resources {
+		listener := &listenerv3.Listener{}
+		require.NoError(resource.UnmarshalTo(listener))
+		names = append(names, listener.Name)
+	}
+	require.Equal([]string{valid}, names)
+	require.Eventually(func() bool { return len(kind.Sources()) == 1 }, 1*time.Second, 1*time.Millisecond)
+	require.Never(func() bool { return len(kind.Sources()) > 1 }, 100*time.Millisecond, 1*time.Millisecond)
+}
+
+func registerXDS(ctx context.Context, registry *sources.Registry, server *grpc.Server) error {
+	servicer, err := NewEndpointServicer(ctx, registry, Options{})
+	if err != nil {
+		return err
+	}
+	xdsServer, err := NewXDSServer(ctx, servicer)
+	if err != nil {
+		return err
+	}
+	discoverygrpc.RegisterAggregatedDiscoveryServiceServer(server, xdsServer)
+	return nil
+}
+
+// xdsWeights returns the weights of the endpoints in the load assignment by
+// address.
+func xdsWeights(t *testing.T, name string, resource *anypb.Any) map[string]uint32 {
+	assignment := &endpointv3.ClusterLoadAssignment{}
+	require.NoError(t, resource.UnmarshalTo(assignment))
+	require.Equal(t, name, assignment.ClusterName)
+
+	weights := map[string]uint32{}
+	for _, locality := range assignment.Endpoints {
+		for _, endpoint := range locality.LbEndpoints {
+			weights[endpoint.GetEndpoint().Address.GetSocketAddress().Address] = endpoint.LoadBalancingWeight.Value
+		}
+	}
+	return weights
+}
@@ -0,0 +1,264 @@
+package endpointslice
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/protobuf/proto"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	discoverylisters "k8s.io/client-go/listers/discovery/v1"
+	"k8s.io/client-go/tools/cache"
+
+	v1 "github.com/authzed/servok/internal/proto/servok/api/v1"
+	"github.com/authzed/servok/internal/sources"
+)
+
+// syncTimeout is how long to wait for EndpointSlices to sync before warning
+// that they haven't, and again after each warning.
+const syncTimeout = 30 * time.Second
+
+// Kind serves WatchRequest.KubernetesRequest by watching the EndpointSlices
+// that belong to a Kubernetes service.
+type Kind struct {
+	Client kubernetes.Interface
+
+	listErrs sources.ErrorReporter
+}
+
+func (k *Kind) Key(request *v1.WatchRequest) string {
+	kr := request.GetKubernetes()
+	key := fmt.Sprintf("%s/%s:%s", kr.Namespace, kr.ServiceName, kr.PortName)
+	if kr.IncludeUnready {
+		key += "+unready"
+	}
+	return key
+}
+
+func (k *Kind) New(ctx context.Context, request *v1.WatchRequest) (sources.Endpoint, error) {
+	kr := request.GetKubernetes()
+	errs := sources.NewLatestError()
+	source := newEndpointSliceSource(ctx, k.Client, kr.Namespace, kr.ServiceName, kr.PortName, kr.IncludeUnready, errs)
+	k.listErrs.Track(ctx, source, errs)
+	return source, nil
+}
+
+// Errors reports when the source's EndpointSlices have not synced within the
+// sync timeout or can't be listed, during which it keeps serving its last
+// known endpoints.
+func (k *Kind) Errors(source sources.Endpoint) <-chan error {
+	return k.listErrs.Errors(source)
+}
+
+// NewEndpointSliceSource watches the EndpointSlices of a service and emits the
+// addresses that serve the named port whenever they change. It returns
+// without waiting for the EndpointSlices to sync, so that a slow API server
+// only delays the first update.
+func NewEndpointSliceSource(ctx context.Context, client kubernetes.Interface, namespace, service, portName string, includeUnready bool) (sources.Endpoint, error) {
+	return newEndpointSliceSource(ctx, client, namespace, service, portName, includeUnready, nil), nil
+}
+
+func newEndpointSliceSource(ctx context.Context, client kubernetes.Interface, namespace, service, portName string, includeUnready bool, errs *sources.LatestError) sources.Endpoint {
+	factory := informers.NewSharedInformerFactoryWithOptions(client, 0,
+		informers.WithNamespace(namespace),
+		informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+			opts.LabelSelector = labels.SelectorFromSet(labels.Set{
+				discoveryv1.LabelServiceName: service,
+			}).String()
+		}),
+	)
+	informer := factory.Discovery().V1().EndpointSlices()
+
+	changed := make(chan struct{}, 1)
+	notify := func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	}
+	informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(interface{}) { notify() },
+		UpdateFunc: func(interface{}, interface{}) { notify() },
+		DeleteFunc: func(interface{}) { notify() },
+	})
+
+	informerCtx, cancel := context.WithCancel(ctx)
+	factory.Start(informerCtx.Done())
+
+	updateChan := make(chan []*v1.Endpoint)
+
+	log.Info().Str("namespace", namespace).Str("service", service).Str("port", portName).Msg("starting Kubernetes EndpointSlice endpoint source")
+	go func() {
+		defer cancel()
+		run(informerCtx, updateChan, changed, informer.Informer().HasSynced, informer.Lister().EndpointSlices(namespace), service, portName, includeUnready, errs)
+	}()
+
+	return updateChan
+}
+
+func run(ctx context.Context,
+	updates chan<- []*v1.Endpoint,
+	changed <-chan struct{},
+	synced cache.InformerSynced,
+	lister discoverylisters.EndpointSliceNamespaceLister,
+	service, portName string,
+	includeUnready bool,
+	errs *sources.LatestError) {
+
+	defer close(updates)
+
+	if !waitForSync(ctx, synced, syncTimeout, errs) {
+		log.Info().Msg("stopping Kubernetes EndpointSlice endpoint source")
+		return
+	}
+
+	selector := labels.SelectorFromSet(labels.Set{discoveryv1.LabelServiceName: service})
+
+	var last *v1.WatchResponse
+	failed := false
+	stop := false
+	for !stop {
+		slices, err := lister.List(selector)
+		if err != nil {
+			log.Error().Err(err).Msg("error listing Kubernetes EndpointSlices, serving last known endpoints")
+			errs.Set(err)
+			failed = true
+			select {
+			case <-ctx.Done():
+				stop = true
+			case <-changed:
+			}
+			continue
+		}
+		if failed {
+			errs.Set(nil)
+			failed = false
+		}
+		endpoints := rewriteAndSortSlices(slices, portName, includeUnready)
+
+		next := &v1.WatchResponse{Endpoints: endpoints}
+		if last == nil || !proto.Equal(last, next) {
+			numEntries := len(endpoints)
+			log.Debug().Int("numEntries", numEntries).Msg("writing EndpointSlice updates to the channel")
+			select {
+			case updates <- endpoints:
+			case <-ctx.Done():
+				stop = true
+			}
+		}
+		last = next
+
+		select {
+		case <-ctx.Done():
+			stop = true
+		case <-changed:
+		}
+	}
+
+	log.Info().Msg("stopping Kubernetes EndpointSlice endpoint source")
+}
+
+// waitForSync waits until the EndpointSlices have synced, reporting each
+// timeout that they haven't, and returns false if the context ends first.
+func waitForSync(ctx context.Context, synced cache.InformerSynced, timeout time.Duration, errs *sources.LatestError) bool {
+	for waited := time.Duration(0); ; waited += timeout {
+		syncCtx, cancel := context.WithTimeout(ctx, timeout)
+		ok := cache.WaitForCacheSync(syncCtx.Done(), synced)
+		cancel()
+		if ok {
+			if waited > 0 {
+				errs.Set(nil)
+			}
+			return true
+		}
+		if ctx.Err() != nil {
+			return false
+		}
+		log.Warn().Stringer("timeout", timeout).Msg("EndpointSlices have not synced, still waiting")
+		errs.Set(fmt.Errorf("EndpointSlices have not synced after %v", waited+timeout))
+	}
+}
+
+func rewriteAndSortSlices(slices []*discoveryv1.EndpointSlice, portName string, includeUnready bool) []*v1.Endpoint {
+	seen := map[string]struct{}{}
+
+	var resolved []*v1.Endpoint
+	for _, slice := range slices {
+		port, ok := findPort(slice, portName)
+		if !ok {
+			continue
+		}
+
+		for _, sliceEndpoint := range slice.Endpoints {
+			conditions := rewriteConditions(sliceEndpoint.Conditions)
+			if !includeUnready && !conditions.Ready {
+				continue
+			}
+
+			for _, address := range sliceEndpoint.Addresses {
+				// EndpointSlices may briefly overlap while they are being
+				// rebalanced by the controller.
+				key := fmt.Sprintf("%s:%d", address, port)
+				if _, ok := seen[key]; ok {
+					continue
+				}
+				seen[key] = struct{}{}
+
+				resolved = append(resolved, &v1.Endpoint{
+					Hostname:   address,
+					Port:       port,
+					Weight:     1,
+					Conditions: conditions,
+				})
+			}
+		}
+	}
+
+	sort.Slice(resolved, func(li, ri int) bool {
+		left, right := resolved[li], resolved[ri]
+		if left.Hostname != right.Hostname {
+			return left.Hostname < right.Hostname
+		}
+		return left.Port < right.Port
+	})
+
+	return resolved
+}
+
+func findPort(slice *discoveryv1.EndpointSlice, portName string) (uint32, bool) {
+	for _, port := range slice.Ports {
+		name := ""
+		if port.Name != nil {
+			name = *port.Name
+		}
+		if name == portName && port.Port != nil {
+			return uint32(*port.Port), true
+		}
+	}
+	return 0, false
+}
+
+// rewriteConditions applies the defaults documented by the EndpointSlice API
+// for conditions that are not set.
+func rewriteConditions(conditions discoveryv1.EndpointConditions) *v1.Endpoint_Conditions {
+	ready := conditions.Ready == nil || *conditions.Ready
+
+	serving := ready
+	if conditions.Serving != nil {
+		serving = *conditions.Serving
+	}
+
+	terminating := conditions.Terminating != nil && *conditions.Terminating
+
+	return &v1.Endpoint_Conditions{
+		Ready:       ready,
+		Serving:     serving,
+		Terminating: terminating,
+	}
+}
@@ -0,0 +1,326 @@
+package endpointslice
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/testing/protocmp"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	discoverylisters "k8s.io/client-go/listers/discovery/v1"
+	k8stesting "k8s.io/client-go/testing"
+
+	v1 "github.com/authzed/servok/internal/proto/servok/api/v1"
+	"github.com/authzed/servok/internal/sources"
+)
+
+func newSlice(name, service string, portName *string, port int32, endpoints ...discoveryv1.Endpoint) *discoveryv1.EndpointSlice {
+	return &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels:    map[string]string{discoveryv1.LabelServiceName: service},
+		},
+		AddressType: discoveryv1.AddressTypeIPv4,
+		Endpoints:   endpoints,
+		Ports:       []discoveryv1.EndpointPort{{Name: portName, Port: &port}},
+	}
+}
+
+func newEndpoint(ready, serving, terminating *bool, addresses ...string) discoveryv1.Endpoint {
+	return discoveryv1.Endpoint{
+		Addresses: addresses,
+		Conditions: discoveryv1.EndpointConditions{
+			Ready:       ready,
+			Serving:     serving,
+			Terminating: terminating,
+		},
+	}
+}
+
+func boolPtr(b bool) *bool       { return &b }
+func stringPtr(s string) *string { return &s }
+
+var (
+	readyConditions       = &v1.Endpoint_Conditions{Ready: true, Serving: true}
+	terminatingConditions = &v1.Endpoint_Conditions{Serving: true, Terminating: true}
+)
+
+func TestRewriteSlices(t *testing.T) {
+	testCases := []struct {
+		name           string
+		slices         []*discoveryv1.EndpointSlice
+		portName       string
+		includeUnready bool
+		expected       []*v1.Endpoint
+	}{
+		{
+			"unnamed port",
+			[]*discoveryv1.EndpointSlice{
+				newSlice("foo-1", "foo", nil, 50051, newEndpoint(nil, nil, nil, "10.0.0.2", "10.0.0.1")),
+			},
+			"",
+			false,
+			[]*v1.Endpoint{
+				{Hostname: "10.0.0.1", Port: 50051, Weight: 1, Conditions: readyConditions},
+				{Hostname: "10.0.0.2", Port: 50051, Weight: 1, Conditions: readyConditions},
+			},
+		},
+		{
+			"named port",
+			[]*discoveryv1.EndpointSlice{
+				newSlice("foo-1", "foo", stringPtr("grpc"), 50051, newEndpoint(nil, nil, nil, "10.0.0.1")),
+				newSlice("foo-2", "foo", stringPtr("http"), 8080, newEndpoint(nil, nil, nil, "10.0.0.1")),
+			},
+			"http",
+			false,
+			[]*v1.Endpoint{
+				{Hostname: "10.0.0.1", Port: 8080, Weight: 1, Conditions: readyConditions},
+			},
+		},
+		{
+			"overlapping slices",
+			[]*discoveryv1.EndpointSlice{
+				newSlice("foo-1", "foo", nil, 50051, newEndpoint(nil, nil, nil, "10.0.0.1")),
+				newSlice("foo-2", "foo", nil, 50051, newEndpoint(nil, nil, nil, "10.0.0.1")),
+			},
+			"",
+			false,
+			[]*v1.Endpoint{
+				{Hostname: "10.0.0.1", Port: 50051, Weight: 1, Conditions: readyConditions},
+			},
+		},
+		{
+			"exclude unready",
+			[]*discoveryv1.EndpointSlice{
+				newSlice("foo-1", "foo", nil, 50051,
+					newEndpoint(boolPtr(true), nil, nil, "10.0.0.1"),
+					newEndpoint(boolPtr(false), boolPtr(true), boolPtr(true), "10.0.0.2"),
+				),
+			},
+			"",
+			false,
+			[]*v1.Endpoint{
+				{Hostname: "10.0.0.1", Port: 50051, Weight: 1, Conditions: readyConditions},
+			},
+		},
+		{
+			"include unready",
+			[]*discoveryv1.EndpointSlice{
+				newSlice("foo-1", "foo", nil, 50051,
+					newEndpoint(boolPtr(true), nil, nil, "10.0.0.1"),
+					newEndpoint(boolPtr(false), boolPtr(true), boolPtr(true), "10.0.0.2"),
+				),
+			},
+			"",
+			true,
+			[]*v1.Endpoint{
+				{Hostname: "10.0.0.1", Port: 50051, Weight: 1, Conditions: readyConditions},
+				{Hostname: "10.0.0.2", Port: 50051, Weight: 1, Conditions: terminatingConditions},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			rewrittenResponse := &v1.WatchResponse{Endpoints: rewriteAndSortSlices(tc.slices, tc.portName, tc.includeUnready)}
+			expectedResponse := &v1.WatchResponse{Endpoints: tc.expected}
+
+			require.Empty(cmp.Diff(expectedResponse, rewrittenResponse, protocmp.Transform()))
+		})
+	}
+}
+
+func TestEndpointSliceSource(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := fake.NewSimpleClientset(
+		newSlice("foo-1", "foo", nil, 50051, newEndpoint(nil, nil, nil, "10.0.0.1")),
+		newSlice("bar-1", "bar", nil, 50051, newEndpoint(nil, nil, nil, "10.0.0.9")),
+	)
+
+	updateChan, err := NewEndpointSliceSource(ctx, client, "default", "foo", "", false)
+	require.NoError(err)
+
+	expectUpdate := func(expectedHostnames ...string) {
+		select {
+		case update, ok := <-updateChan:
+			require.True(ok)
+			var hostnames []string
+			for _, endpoint := range update {
+				hostnames = append(hostnames, endpoint.Hostname)
+			}
+			require.Equal(expectedHostnames, hostnames)
+		case <-time.After(5 * time.Second):
+			require.Fail("timed out waiting for update")
+		}
+	}
+
+	expectUpdate("10.0.0.1")
+
+	slices := client.DiscoveryV1().EndpointSlices("default")
+	_, err = slices.Create(ctx, newSlice("foo-2", "foo", nil, 50051, newEndpoint(nil, nil, nil, "10.0.0.2")), metav1.CreateOptions{})
+	require.NoError(err)
+	expectUpdate("10.0.0.1", "10.0.0.2")
+
+	_, err = slices.Update(ctx, newSlice("foo-1", "foo", nil, 50051, newEndpoint(boolPtr(false), nil, nil, "10.0.0.1")), metav1.UpdateOptions{})
+	require.NoError(err)
+	expectUpdate("10.0.0.2")
+
+	require.NoError(slices.Delete(ctx, "foo-2", metav1.DeleteOptions{}))
+	expectUpdate()
+
+	cancel()
+
+	require.Eventually(func() bool {
+		select {
+		case _, ok := <-updateChan:
+			return !ok
+		default:
+			return false
+		}
+	}, 5*time.Second, 1*time.Millisecond)
+}
+
+func TestEndpointSliceSourceSyncsAsynchronously(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Listing EndpointSlices blocks until the API server is released
+	release := make(chan struct{})
+	client := fake.NewSimpleClientset(newSlice("foo-1", "foo", nil, 50051, newEndpoint(nil, nil, nil, "10.0.0.1")))
+	client.PrependReactor("list", "endpointslices", func(k8stesting.Action) (bool, runtime.Object, error) {
+		<-release
+		return false, nil, nil
+	})
+
+	start := time.Now()
+	updateChan, err := NewEndpointSliceSource(ctx, client, "default", "foo", "", false)
+	require.NoError(err)
+	require.Less(int64(time.Since(start)), int64(1*time.Second))
+
+	select {
+	case <-updateChan:
+		require.Fail("unexpected update before the EndpointSlices synced")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case update, ok := <-updateChan:
+		require.True(ok)
+		require.Len(update, 1)
+	case <-time.After(5 * time.Second):
+		require.Fail("timed out waiting for update")
+	}
+}
+
+func TestWaitForSyncReportsTimeouts(t *testing.T) {
+	require := require.New(t)
+
+	var synced int32
+	errs := sources.NewLatestError()
+	done := make(chan bool)
+	go func() {
+		done <- waitForSync(context.Background(), func() bool { return atomic.LoadInt32(&synced) == 1 }, 10*time.Millisecond, errs)
+	}()
+
+	select {
+	case err := <-errs.Errors():
+		require.Error(err)
+	case <-time.After(5 * time.Second):
+		require.FailNow("timed out waiting for error")
+	}
+
+	atomic.StoreInt32(&synced, 1)
+	select {
+	case ok := <-done:
+		require.True(ok)
+	case <-time.After(5 * time.Second):
+		require.FailNow("timed out waiting for sync")
+	}
+	require.NoError(<-errs.Errors())
+}
+
+// failingLister lists its slices unless it has been told to fail.
+type failingLister struct {
+	discoverylisters.EndpointSliceNamespaceLister
+
+	sync.Mutex
+	err error
+}
+
+func (l *failingLister) List(labels.Selector) ([]*discoveryv1.EndpointSlice, error) {
+	l.Lock()
+	defer l.Unlock()
+	return nil, l.err
+}
+
+func (l *failingLister) setErr(err error) {
+	l.Lock()
+	defer l.Unlock()
+	l.err = err
+}
+
+func TestRunReportsListErrors(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	lister := &failingLister{err: errors.New("list failed")}
+	updates := make(chan []*v1.Endpoint)
+	changed := make(chan struct{})
+	errs := sources.NewLatestError()
+	go run(ctx, updates, changed, func() bool { return true }, lister, "foo", "", false, errs)
+
+	// The source keeps running while its EndpointSlices can't be listed
+	select {
+	case err := <-errs.Errors():
+		require.Error(err)
+	case <-time.After(5 * time.Second):
+		require.FailNow("timed out waiting for error")
+	}
+
+	lister.setErr(nil)
+	changed <- struct{}{}
+	select {
+	case update, ok := <-updates:
+		require.True(ok)
+		require.Empty(update)
+	case <-time.After(5 * time.Second):
+		require.FailNow("timed out waiting for update")
+	}
+	require.NoError(<-errs.Errors())
+}
+
+func TestKindErrors(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	kind := &Kind{Client: fake.NewSimpleClientset()}
+	source, err := kind.New(ctx, &v1.WatchRequest{RequestTypeOneof: &v1.WatchRequest_Kubernetes{
+		Kubernetes: &v1.WatchRequest_KubernetesRequest{Namespace: "default", ServiceName: "foo"},
+	}})
+	require.NoError(t, err)
+	require.NotNil(t, kind.Errors(source))
+
+	cancel()
+	require.Eventually(t, func() bool { return kind.Errors(source) == nil }, 1*time.Second, 1*time.Millisecond)
+}
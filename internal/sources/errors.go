@@ -0,0 +1,64 @@
+package sources
+
+import (
+	"context"
+	"sync"
+)
+
+// LatestError holds the error of a source's latest failed refresh, or nil
+// once a refresh succeeds after a failure, until its watcher takes it.
+type LatestError struct {
+	errs chan error
+}
+
+func NewLatestError() *LatestError {
+	return &LatestError{errs: make(chan error, 1)}
+}
+
+// Set replaces any error that has not yet been taken. It does nothing on a
+// nil LatestError, so that sources started without one needn't check.
+func (e *LatestError) Set(err error) {
+	if e == nil {
+		return
+	}
+
+	for {
+		select {
+		case e.errs <- err:
+			return
+		default:
+		}
+		select {
+		case <-e.errs:
+		default:
+		}
+	}
+}
+
+// Errors returns the channel that the errors are taken from.
+func (e *LatestError) Errors() <-chan error {
+	return e.errs
+}
+
+// ErrorReporter implements Reporter for a Kind by tracking the LatestError of
+// each of its sources. The zero value is ready to use.
+type ErrorReporter struct {
+	errs sync.Map // Endpoint -> *LatestError
+}
+
+// Track reports the errors of the source until the context is done.
+func (r *ErrorReporter) Track(ctx context.Context, source Endpoint, errs *LatestError) {
+	r.errs.Store(source, errs)
+	go func() {
+		<-ctx.Done()
+		r.errs.Delete(source)
+	}()
+}
+
+func (r *ErrorReporter) Errors(source Endpoint) <-chan error {
+	errs, ok := r.errs.Load(source)
+	if !ok {
+		return nil
+	}
+	return errs.(*LatestError).Errors()
+}
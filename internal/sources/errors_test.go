@@ -0,0 +1,48 @@
+package sources
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	v1 "github.com/authzed/servok/internal/proto/servok/api/v1"
+)
+
+func TestLatestError(t *testing.T) {
+	require := require.New(t)
+
+	var unset *LatestError
+	unset.Set(errors.New("ignored"))
+
+	errs := NewLatestError()
+	errs.Set(errors.New("first"))
+	errs.Set(errors.New("second"))
+	require.EqualError(<-errs.Errors(), "second")
+
+	errs.Set(errors.New("third"))
+	errs.Set(nil)
+	require.NoError(<-errs.Errors())
+	require.Empty(errs.Errors())
+}
+
+func TestErrorReporter(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var reporter ErrorReporter
+	source := Endpoint(make(chan []*v1.Endpoint))
+	require.Nil(reporter.Errors(source))
+
+	errs := NewLatestError()
+	reporter.Track(ctx, source, errs)
+	errs.Set(errors.New("failed"))
+	require.EqualError(<-reporter.Errors(source), "failed")
+
+	cancel()
+	require.Eventually(func() bool { return reporter.Errors(source) == nil }, 1*time.Second, 1*time.Millisecond)
+}
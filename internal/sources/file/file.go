@@ -0,0 +1,181 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"sigs.k8s.io/yaml"
+
+	v1 "github.com/authzed/servok/internal/proto/servok/api/v1"
+	"github.com/authzed/servok/internal/sources"
+)
+
+// Kind serves WatchRequest.FileRequest by reading endpoints from files within
+// a directory.
+type Kind struct {
+	Dir          string
+	UpdatePeriod time.Duration
+
+	readErrs sources.ErrorReporter
+}
+
+func (k *Kind) Key(request *v1.WatchRequest) string {
+	return request.GetFile().Name
+}
+
+func (k *Kind) New(ctx context.Context, request *v1.WatchRequest) (sources.Endpoint, error) {
+	errs := sources.NewLatestError()
+	source, err := newFileSource(ctx, filepath.Join(k.Dir, request.GetFile().Name), k.UpdatePeriod, errs)
+	if err != nil {
+		return nil, err
+	}
+	k.readErrs.Track(ctx, source, errs)
+	return source, nil
+}
+
+// Errors reports the failed reads of the source's file, during which it keeps
+// serving its last known endpoints.
+func (k *Kind) Errors(source sources.Endpoint) <-chan error {
+	return k.readErrs.Errors(source)
+}
+
+// NewFileSource reads endpoints from a YAML or JSON file with the same schema
+// as a WatchResponse and emits them again whenever the file changes.
+func NewFileSource(ctx context.Context, path string, updatePeriod time.Duration) (sources.Endpoint, error) {
+	return newFileSource(ctx, path, updatePeriod, nil)
+}
+
+func newFileSource(ctx context.Context, path string, updatePeriod time.Duration, errs *sources.LatestError) (sources.Endpoint, error) {
+	if updatePeriod <= 0 {
+		return nil, fmt.Errorf("file update period must be positive, got %v", updatePeriod)
+	}
+	if _, err := readEndpoints(path); err != nil {
+		return nil, err
+	}
+
+	updateChan := make(chan []*v1.Endpoint)
+
+	log.Info().Stringer("period", updatePeriod).Str("path", path).Msg("starting file endpoint source")
+	go run(ctx, updateChan, path, updatePeriod, errs)
+
+	return updateChan, nil
+}
+
+func run(ctx context.Context,
+	updates chan<- []*v1.Endpoint,
+	path string,
+	updatePeriod time.Duration,
+	errs *sources.LatestError) {
+
+	defer close(updates)
+
+	ticker := time.NewTicker(updatePeriod)
+	defer ticker.Stop()
+
+	var lastModified, polledModified time.Time
+	var lastSize, polledSize int64
+	var last *v1.WatchResponse
+	failed := false
+
+	stop := false
+	for !stop {
+		info, err := os.Stat(path)
+		switch {
+		case err != nil:
+			// The file is reported as failing below.
+		case last != nil && info.ModTime().Equal(lastModified) && info.Size() == lastSize:
+			// The file hasn't changed since it was last read.
+		case last != nil && (!info.ModTime().Equal(polledModified) || info.Size() != polledSize):
+			// Files that are rewritten in place can be read half written, so
+			// changes are only read once the file is unchanged since the
+			// previous poll.
+		default:
+			var endpoints []*v1.Endpoint
+			endpoints, err = readEndpoints(path)
+			if err != nil {
+				break
+			}
+			lastModified, lastSize = info.ModTime(), info.Size()
+
+			next := &v1.WatchResponse{Endpoints: endpoints}
+			if last == nil || !proto.Equal(last, next) {
+				numEntries := len(endpoints)
+				log.Debug().Int("numEntries", numEntries).Msg("writing file updates to the channel")
+				select {
+				case updates <- endpoints:
+				case <-ctx.Done():
+					stop = true
+				}
+			}
+			last = next
+		}
+		if info != nil {
+			polledModified, polledSize = info.ModTime(), info.Size()
+		}
+
+		switch {
+		case err != nil:
+			log.Error().Err(err).Str("path", path).Msg("error reading endpoints file, serving last known endpoints")
+			errs.Set(err)
+			failed = true
+		case failed && info.ModTime().Equal(lastModified) && info.Size() == lastSize:
+			// The file has been read since the failure, or is unchanged
+			// since it last was.
+			errs.Set(nil)
+			failed = false
+		}
+
+		select {
+		case <-ctx.Done():
+			stop = true
+		case <-ticker.C:
+		}
+	}
+
+	log.Info().Msg("stopping file endpoint source")
+}
+
+func readEndpoints(path string) ([]*v1.Endpoint, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// YAML is a superset of JSON, so both are normalized to JSON before
+	// being decoded with the protobuf JSON mapping.
+	jsonContents, err := yaml.YAMLToJSON(contents)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse endpoints file: %w", err)
+	}
+
+	if string(jsonContents) == "null" {
+		// An empty file is more likely to be one that is being written than
+		// one without endpoints, which is written as an empty list instead.
+		return nil, errors.New("endpoints file is empty")
+	}
+
+	var parsed v1.WatchResponse
+	if err := protojson.Unmarshal(jsonContents, &parsed); err != nil {
+		return nil, fmt.Errorf("unable to parse endpoints file: %w", err)
+	}
+
+	endpoints := parsed.Endpoints
+	sort.SliceStable(endpoints, func(li, ri int) bool {
+		left, right := endpoints[li], endpoints[ri]
+		if left.Hostname != right.Hostname {
+			return strings.Compare(left.Hostname, right.Hostname) < 0
+		}
+		return left.Port < right.Port
+	})
+
+	return endpoints, nil
+}
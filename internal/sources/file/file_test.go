@@ -0,0 +1,198 @@
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	v1 "github.com/authzed/servok/internal/proto/servok/api/v1"
+)
+
+func TestReadEndpoints(t *testing.T) {
+	testCases := []struct {
+		name        string
+		contents    string
+		expected    []*v1.Endpoint
+		expectedErr bool
+	}{
+		{"empty file", "", nil, true},
+		{"no endpoints", "endpoints: []", nil, false},
+		{
+			"yaml",
+			`
+endpoints:
+- hostname: host2
+  port: 50051
+  weight: 1
+- hostname: host1
+  port: 50051
+  weight: 2
+`,
+			[]*v1.Endpoint{
+				{Hostname: "host1", Port: 50051, Weight: 2},
+				{Hostname: "host2", Port: 50051, Weight: 1},
+			},
+			false,
+		},
+		{
+			"json",
+			`{"endpoints": [{"hostname": "host1", "port": 50051, "weight": 1}]}`,
+			[]*v1.Endpoint{
+				{Hostname: "host1", Port: 50051, Weight: 1},
+			},
+			false,
+		},
+		{"unknown field", `{"endpoints": [{"address": "host1"}]}`, nil, true},
+		{"malformed", "endpoints: [", nil, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			path := filepath.Join(t.TempDir(), "endpoints.yaml")
+			require.NoError(os.WriteFile(path, []byte(tc.contents), 0o600))
+
+			endpoints, err := readEndpoints(path)
+			if tc.expectedErr {
+				require.Error(err)
+				return
+			}
+			require.NoError(err)
+
+			rewrittenResponse := &v1.WatchResponse{Endpoints: endpoints}
+			expectedResponse := &v1.WatchResponse{Endpoints: tc.expected}
+			require.Empty(cmp.Diff(expectedResponse, rewrittenResponse, protocmp.Transform()))
+		})
+	}
+}
+
+func TestFileSource(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	path := filepath.Join(t.TempDir(), "endpoints.yaml")
+	writeFile := func(contents string, modified time.Time) {
+		require.NoError(os.WriteFile(path, []byte(contents), 0o600))
+		require.NoError(os.Chtimes(path, modified, modified))
+	}
+
+	_, err := NewFileSource(ctx, path, 1*time.Millisecond)
+	require.Error(err)
+
+	start := time.Now()
+	writeFile("endpoints: [{hostname: host1, port: 50051}]", start)
+
+	// Periods that can't be polled at are rejected rather than panicking
+	_, err = NewFileSource(ctx, path, 0)
+	require.Error(err)
+
+	updateChan, err := NewFileSource(ctx, path, 1*time.Millisecond)
+	require.NoError(err)
+
+	expectUpdate := func(expectedCount int) {
+		select {
+		case update, ok := <-updateChan:
+			require.True(ok)
+			require.Len(update, expectedCount)
+		case <-time.After(1 * time.Second):
+			require.Fail("timed out waiting for update")
+		}
+	}
+
+	expectUpdate(1)
+
+	writeFile("endpoints: [{hostname: host1, port: 50051}, {hostname: host2, port: 50051}]", start.Add(1*time.Second))
+	expectUpdate(2)
+
+	// Touching the file without changing the endpoints is suppressed, as is
+	// a malformed file.
+	writeFile("endpoints: [{hostname: host1, port: 50051}, {hostname: host2, port: 50051}]", start.Add(2*time.Second))
+	writeFile("endpoints: [", start.Add(3*time.Second))
+	select {
+	case <-updateChan:
+		require.Fail("unexpected update")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	writeFile("endpoints: []", start.Add(4*time.Second))
+	expectUpdate(0)
+
+	cancel()
+
+	require.Eventually(func() bool {
+		select {
+		case _, ok := <-updateChan:
+			return !ok
+		default:
+			return false
+		}
+	}, 100*time.Millisecond, 1*time.Millisecond)
+}
+
+func TestKindErrors(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "endpoints.yaml")
+	start := time.Now()
+	writeFile := func(contents string, modified time.Time) {
+		require.NoError(os.WriteFile(path, []byte(contents), 0o600))
+		require.NoError(os.Chtimes(path, modified, modified))
+	}
+	writeFile("endpoints: [{hostname: host1, port: 50051}]", start)
+
+	kind := &Kind{Dir: dir, UpdatePeriod: 1 * time.Millisecond}
+	source, err := kind.New(ctx, &v1.WatchRequest{RequestTypeOneof: &v1.WatchRequest_File{
+		File: &v1.WatchRequest_FileRequest{Name: "endpoints.yaml"},
+	}})
+	require.NoError(err)
+	go func() {
+		for range source {
+		}
+	}()
+
+	errs := kind.Errors(source)
+	require.NotNil(errs)
+	// Failing reads are reported on every poll, so earlier errors may still
+	// be taken after the file is fixed.
+	expectErr := func(expectErr bool) {
+		timeout := time.After(1 * time.Second)
+		for {
+			select {
+			case err := <-errs:
+				if (err != nil) == expectErr {
+					return
+				}
+			case <-timeout:
+				require.FailNow("timed out waiting for error", "expected error: %v", expectErr)
+			}
+		}
+	}
+
+	// Malformed files are reported until they are fixed
+	writeFile("endpoints: [", start.Add(1*time.Second))
+	expectErr(true)
+	writeFile("endpoints: [{hostname: host1, port: 50051}]", start.Add(2*time.Second))
+	expectErr(false)
+
+	// As are missing files, until they are back as they were
+	require.NoError(os.Remove(path))
+	expectErr(true)
+	writeFile("endpoints: [{hostname: host1, port: 50051}]", start.Add(2*time.Second))
+	expectErr(false)
+
+	cancel()
+	require.Eventually(func() bool { return kind.Errors(source) == nil }, 1*time.Second, 1*time.Millisecond)
+}
@@ -0,0 +1,87 @@
+package sources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	v1 "github.com/authzed/servok/internal/proto/servok/api/v1"
+)
+
+// ErrNoRequestType is returned when a WatchRequest does not set any variant of
+// its request_type_oneof.
+var ErrNoRequestType = errors.New("no request type specified")
+
+var requestTypes = (&v1.WatchRequest{}).ProtoReflect().Descriptor().Oneofs().ByName("request_type_oneof")
+
+// Kind constructs endpoint sources for a single variant of WatchRequest's
+// request_type_oneof.
+type Kind interface {
+	// Key returns the canonical name of the target being watched. Requests
+	// that produce the same key share a single source.
+	Key(request *v1.WatchRequest) string
+
+	// New starts a source for the request which runs until the context is
+	// canceled.
+	New(ctx context.Context, request *v1.WatchRequest) (Endpoint, error)
+}
+
+// Tuner is optionally implemented by Kinds whose sources can adapt to the
+// requests of every client that shares them, such as by refreshing at the
+// fastest rate that any of them asked for.
+type Tuner interface {
+	// Tune is called with the requests of all of the clients sharing a
+	// source whenever a client joins or leaves it.
+	Tune(source Endpoint, requests []*v1.WatchRequest)
+}
+
+// Reporter is optionally implemented by Kinds whose sources keep serving
+// their last known endpoints when they fail to refresh them, so that those
+// failures can still be surfaced.
+type Reporter interface {
+	// Errors returns a channel that receives the error of each failed
+	// refresh of the source, and nil once a refresh succeeds again. Only the
+	// latest error is kept for a receiver that falls behind.
+	Errors(source Endpoint) <-chan error
+}
+
+// Registry dispatches WatchRequests to the Kind registered for the variant of
+// request_type_oneof that they set.
+type Registry struct {
+	kinds map[protoreflect.Name]Kind
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{kinds: map[protoreflect.Name]Kind{}}
+}
+
+// Register installs the Kind that serves the named request_type_oneof field.
+// It panics if the field does not exist or is already registered.
+func (r *Registry) Register(field protoreflect.Name, kind Kind) {
+	if requestTypes.Fields().ByName(field) == nil {
+		panic(fmt.Sprintf("unknown request type: %s", field))
+	}
+	if _, ok := r.kinds[field]; ok {
+		panic(fmt.Sprintf("request type already registered: %s", field))
+	}
+	r.kinds[field] = kind
+}
+
+// Lookup returns the Kind that serves the request along with the request's
+// watch key, which is prefixed with the name of the request type.
+func (r *Registry) Lookup(request *v1.WatchRequest) (Kind, string, error) {
+	field := request.ProtoReflect().WhichOneof(requestTypes)
+	if field == nil {
+		return nil, "", ErrNoRequestType
+	}
+
+	kind, ok := r.kinds[field.Name()]
+	if !ok {
+		return nil, "", fmt.Errorf("unsupported request type: %s", field.Name())
+	}
+
+	return kind, fmt.Sprintf("%s:%s", field.Name(), kind.Key(request)), nil
+}
@@ -0,0 +1,74 @@
+package sources
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	v1 "github.com/authzed/servok/internal/proto/servok/api/v1"
+)
+
+type fakeKind struct{}
+
+func (fakeKind) Key(request *v1.WatchRequest) string {
+	return request.GetSrv().DnsName
+}
+
+func (fakeKind) New(_ context.Context, _ *v1.WatchRequest) (Endpoint, error) {
+	return make(chan []*v1.Endpoint), nil
+}
+
+func TestRegistryLookup(t *testing.T) {
+	srvRequest := &v1.WatchRequest{
+		RequestTypeOneof: &v1.WatchRequest_Srv{Srv: &v1.WatchRequest_SRVRequest{
+			Service:  "grpc",
+			Protocol: "tcp",
+			DnsName:  "example.com",
+		}},
+	}
+
+	testCases := []struct {
+		name        string
+		registered  bool
+		request     *v1.WatchRequest
+		expectedKey string
+		expectedErr string
+	}{
+		{"registered", true, srvRequest, "srv:example.com", ""},
+		{"unregistered", false, srvRequest, "", "unsupported request type: srv"},
+		{"missing request type", true, &v1.WatchRequest{}, "", ErrNoRequestType.Error()},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			registry := NewRegistry()
+			if tc.registered {
+				registry.Register("srv", fakeKind{})
+			}
+
+			kind, key, err := registry.Lookup(tc.request)
+			if tc.expectedErr != "" {
+				require.EqualError(err, tc.expectedErr)
+				require.Nil(kind)
+				return
+			}
+
+			require.NoError(err)
+			require.Equal(fakeKind{}, kind)
+			require.Equal(tc.expectedKey, key)
+		})
+	}
+}
+
+func TestRegistryRegisterPanics(t *testing.T) {
+	require := require.New(t)
+
+	registry := NewRegistry()
+	require.Panics(func() { registry.Register("unknown", fakeKind{}) })
+
+	registry.Register("srv", fakeKind{})
+	require.Panics(func() { registry.Register("srv", fakeKind{}) })
+}
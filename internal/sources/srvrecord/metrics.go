@@ -0,0 +1,68 @@
+package srvrecord
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Metrics are labeled by the source's key, as returned by Kind.Key, and are
+// removed when the source stops.
+var (
+	lookupsCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "servok",
+		Subsystem: "dns",
+		Name:      "lookups_total",
+		Help:      "Number of DNS lookups made by each SRV source, by record type and result.",
+	}, []string{"source", "type", "result"})
+
+	lookupDurationHistogram = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "servok",
+		Subsystem: "dns",
+		Name:      "lookup_duration_seconds",
+		Help:      "Duration of the DNS lookups made by each SRV source, by record type.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"source", "type"})
+
+	lastResolveGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "servok",
+		Subsystem: "dns",
+		Name:      "last_successful_resolve_timestamp_seconds",
+		Help:      "Unix time at which each SRV source last resolved its records.",
+	}, []string{"source"})
+)
+
+const (
+	recordTypeSRV  = "srv"
+	recordTypeHost = "host"
+)
+
+// observeLookup records a lookup that started at the given time. Names that
+// do not exist are answers rather than failures.
+func observeLookup(source, recordType string, start time.Time, err error) {
+	lookupDurationHistogram.WithLabelValues(source, recordType).Observe(time.Since(start).Seconds())
+
+	result := "success"
+	switch {
+	case isNotFound(err):
+		result = "not_found"
+	case err != nil:
+		result = "error"
+	}
+	lookupsCounter.WithLabelValues(source, recordType, result).Inc()
+
+	if recordType == recordTypeSRV && result != "error" {
+		lastResolveGauge.WithLabelValues(source).SetToCurrentTime()
+	}
+}
+
+func deleteSourceMetrics(source string) {
+	for _, recordType := range []string{recordTypeSRV, recordTypeHost} {
+		lookupDurationHistogram.DeleteLabelValues(source, recordType)
+		for _, result := range []string{"success", "not_found", "error"} {
+			lookupsCounter.DeleteLabelValues(source, recordType, result)
+		}
+	}
+	lastResolveGauge.DeleteLabelValues(source)
+}
@@ -0,0 +1,97 @@
+package srvrecord
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	v1 "github.com/authzed/servok/internal/proto/servok/api/v1"
+)
+
+type switchableResolver struct {
+	sync.Mutex
+	err error
+}
+
+func (r *switchableResolver) setErr(err error) {
+	r.Lock()
+	defer r.Unlock()
+	r.err = err
+}
+
+func (r *switchableResolver) LookupSRV(context.Context, string, string, string) ([]*net.SRV, time.Duration, error) {
+	r.Lock()
+	defer r.Unlock()
+	return []*net.SRV{{Target: "host1.example.com.", Port: 50051}}, 0, r.err
+}
+
+func (r *switchableResolver) LookupHost(context.Context, string) ([]string, time.Duration, error) {
+	return []string{"10.0.0.1"}, 0, nil
+}
+
+func hasSourceSeries(t *testing.T, source string) bool {
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		for _, metric := range family.Metric {
+			for _, label := range metric.Label {
+				if label.GetName() == "source" && label.GetValue() == source {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func TestSourceMetrics(t *testing.T) {
+	require := require.New(t)
+
+	resolver := &switchableResolver{}
+	kind := &Kind{
+		UpdatePeriod: 1 * time.Millisecond,
+		Backoff:      Backoff{Initial: 1 * time.Millisecond, Max: 1 * time.Millisecond},
+		Resolver:     resolver,
+	}
+	request := &v1.WatchRequest{RequestTypeOneof: &v1.WatchRequest_Srv{Srv: &v1.WatchRequest_SRVRequest{
+		Service:          "grpc",
+		Protocol:         "tcp",
+		DnsName:          "metrics.example.com",
+		ResolveAddresses: true,
+	}}}
+	key := kind.Key(request)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	source, err := kind.New(ctx, request)
+	require.NoError(err)
+	<-source
+
+	successes := func(recordType string) float64 {
+		return testutil.ToFloat64(lookupsCounter.WithLabelValues(key, recordType, "success"))
+	}
+	require.Eventually(func() bool { return successes(recordTypeSRV) >= 2 && successes(recordTypeHost) >= 1 }, 1*time.Second, 1*time.Millisecond)
+	lastResolve := testutil.ToFloat64(lastResolveGauge.WithLabelValues(key))
+	require.NotZero(lastResolve)
+
+	resolver.setErr(errors.New("server misbehaving"))
+	require.Eventually(func() bool {
+		return testutil.ToFloat64(lookupsCounter.WithLabelValues(key, recordTypeSRV, "error")) >= 2
+	}, 1*time.Second, 1*time.Millisecond)
+
+	// Failed lookups are not successful resolves
+	failedSince := testutil.ToFloat64(lastResolveGauge.WithLabelValues(key))
+	time.Sleep(20 * time.Millisecond)
+	require.Equal(failedSince, testutil.ToFloat64(lastResolveGauge.WithLabelValues(key)))
+
+	// Once the source stops its series are removed
+	cancel()
+	require.Eventually(func() bool { return !hasSourceSeries(t, key) }, 1*time.Second, 1*time.Millisecond)
+}
@@ -0,0 +1,40 @@
+package srvrecord
+
+import (
+	"sync"
+	"time"
+)
+
+// pollPeriod is how often a source resolves its records, which may change
+// while the source is running.
+type pollPeriod struct {
+	sync.Mutex
+
+	period  time.Duration
+	changed chan struct{}
+}
+
+func newPollPeriod(period time.Duration) *pollPeriod {
+	return &pollPeriod{period: period, changed: make(chan struct{}, 1)}
+}
+
+func (p *pollPeriod) get() time.Duration {
+	p.Lock()
+	defer p.Unlock()
+	return p.period
+}
+
+func (p *pollPeriod) set(period time.Duration) {
+	p.Lock()
+	defer p.Unlock()
+
+	if p.period == period {
+		return
+	}
+	p.period = period
+
+	select {
+	case p.changed <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,298 @@
+package srvrecord
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// Resolver looks up DNS records along with how long the answers may be
+// cached. A TTL of zero means that it is unknown.
+//
+// Names that do not exist are reported with a *net.DNSError that has
+// IsNotFound set.
+type Resolver interface {
+	LookupSRV(ctx context.Context, service, proto, name string) ([]*net.SRV, time.Duration, error)
+	LookupHost(ctx context.Context, host string) ([]string, time.Duration, error)
+}
+
+// SystemResolver resolves names with the Go standard library, which does not
+// report TTLs.
+type SystemResolver struct{}
+
+func (SystemResolver) LookupSRV(ctx context.Context, service, proto, name string) ([]*net.SRV, time.Duration, error) {
+	_, addrs, err := net.DefaultResolver.LookupSRV(ctx, service, proto, name)
+	return addrs, 0, err
+}
+
+func (SystemResolver) LookupHost(ctx context.Context, host string) ([]string, time.Duration, error) {
+	addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+	return addrs, 0, err
+}
+
+// Transports that the DNSResolver can query nameservers over.
+const (
+	TransportUDP   = "udp"
+	TransportTCP   = "tcp"
+	TransportTLS   = "tls"
+	TransportHTTPS = "https"
+)
+
+// DNSResolverOptions configures how a DNSResolver reaches its nameservers.
+type DNSResolverOptions struct {
+	// Nameservers are queried in order until one of them answers. They are
+	// host:port addresses, where the port defaults to 53 (853 for TLS), or
+	// URLs when using HTTPS.
+	Nameservers []string
+
+	// Transport is one of the Transport constants, defaulting to UDP. Answers
+	// over UDP that are truncated are retried over TCP.
+	Transport string
+
+	// Timeout bounds each query to a single nameserver, defaulting to 2s.
+	Timeout time.Duration
+
+	// TLSConfig is used by the TLS and HTTPS transports.
+	TLSConfig *tls.Config
+}
+
+type exchangeFunc func(ctx context.Context, msg *dns.Msg, nameserver string) (*dns.Msg, error)
+
+// DNSResolver queries specific nameservers directly, which allows it to
+// report the TTLs of the answers.
+type DNSResolver struct {
+	nameservers []string
+	timeout     time.Duration
+	exchange    exchangeFunc
+}
+
+// NewDNSResolver creates a Resolver that queries the configured nameservers.
+func NewDNSResolver(opts DNSResolverOptions) (*DNSResolver, error) {
+	if len(opts.Nameservers) == 0 {
+		return nil, errors.New("no nameservers specified")
+	}
+
+	r := &DNSResolver{timeout: opts.Timeout}
+	if r.timeout <= 0 {
+		r.timeout = 2 * time.Second
+	}
+
+	defaultPort := "53"
+	switch opts.Transport {
+	case "", TransportUDP:
+		r.exchange = r.exchangeUDP
+	case TransportTCP:
+		r.exchange = r.exchangeDNS("tcp", nil)
+	case TransportTLS:
+		defaultPort = "853"
+		r.exchange = r.exchangeDNS("tcp-tls", opts.TLSConfig)
+	case TransportHTTPS:
+		client := &http.Client{Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: opts.TLSConfig,
+		}}
+		r.exchange = func(ctx context.Context, msg *dns.Msg, url string) (*dns.Msg, error) {
+			return exchangeHTTPS(ctx, client, msg, url)
+		}
+	default:
+		return nil, fmt.Errorf("unknown DNS transport: %s", opts.Transport)
+	}
+
+	for _, nameserver := range opts.Nameservers {
+		if opts.Transport != TransportHTTPS {
+			if _, _, err := net.SplitHostPort(nameserver); err != nil {
+				nameserver = net.JoinHostPort(nameserver, defaultPort)
+			}
+		}
+		r.nameservers = append(r.nameservers, nameserver)
+	}
+
+	return r, nil
+}
+
+func (r *DNSResolver) LookupSRV(ctx context.Context, service, proto, name string) ([]*net.SRV, time.Duration, error) {
+	answers, ttl, err := r.query(ctx, QualifiedName(service, proto, name), dns.TypeSRV)
+	if err != nil {
+		return nil, ttl, err
+	}
+
+	addrs := make([]*net.SRV, 0, len(answers))
+	for _, answer := range answers {
+		srv := answer.(*dns.SRV)
+		addrs = append(addrs, &net.SRV{
+			Target:   srv.Target,
+			Port:     srv.Port,
+			Priority: srv.Priority,
+			Weight:   srv.Weight,
+		})
+	}
+	return addrs, ttl, nil
+}
+
+func (r *DNSResolver) LookupHost(ctx context.Context, host string) ([]string, time.Duration, error) {
+	var addrs []string
+	var ttl time.Duration
+	for _, qtype := range []uint16{dns.TypeA, dns.TypeAAAA} {
+		answers, answerTTL, err := r.query(ctx, host, qtype)
+		if err != nil {
+			return nil, answerTTL, err
+		}
+		ttl = minTTL(ttl, answerTTL)
+
+		for _, answer := range answers {
+			switch rr := answer.(type) {
+			case *dns.A:
+				addrs = append(addrs, rr.A.String())
+			case *dns.AAAA:
+				addrs = append(addrs, rr.AAAA.String())
+			}
+		}
+	}
+	return addrs, ttl, nil
+}
+
+// query returns the answers of the requested type along with the lowest TTL
+// among them. Empty and nonexistent names use the negative caching TTL from
+// the zone's SOA record, as in RFC 2308.
+//
+// Nameservers that fail to answer are skipped in favor of the next one.
+func (r *DNSResolver) query(ctx context.Context, name string, qtype uint16) ([]dns.RR, time.Duration, error) {
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(name), qtype)
+
+	var err error
+	for _, nameserver := range r.nameservers {
+		var in *dns.Msg
+		in, err = r.exchangeWithTimeout(ctx, msg, nameserver)
+		if err != nil {
+			var netErr net.Error
+			isTimeout := errors.As(err, &netErr) && netErr.Timeout()
+			err = &net.DNSError{Err: err.Error(), Name: name, Server: nameserver, IsTimeout: isTimeout, IsTemporary: true}
+			continue
+		}
+
+		switch in.Rcode {
+		case dns.RcodeSuccess:
+		case dns.RcodeNameError:
+			return nil, negativeTTL(in), &net.DNSError{Err: "no such host", Name: name, Server: nameserver, IsNotFound: true}
+		default:
+			err = &net.DNSError{Err: "server returned " + dns.RcodeToString[in.Rcode], Name: name, Server: nameserver, IsTemporary: true}
+			continue
+		}
+
+		var answers []dns.RR
+		var ttl time.Duration
+		for _, answer := range in.Answer {
+			if answer.Header().Rrtype != qtype {
+				continue
+			}
+			answers = append(answers, answer)
+			ttl = minTTL(ttl, time.Duration(answer.Header().Ttl)*time.Second)
+		}
+		if len(answers) == 0 {
+			ttl = negativeTTL(in)
+		}
+		return answers, ttl, nil
+	}
+	return nil, 0, err
+}
+
+func (r *DNSResolver) exchangeWithTimeout(ctx context.Context, msg *dns.Msg, nameserver string) (*dns.Msg, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.exchange(ctx, msg, nameserver)
+}
+
+func (r *DNSResolver) exchangeUDP(ctx context.Context, msg *dns.Msg, nameserver string) (*dns.Msg, error) {
+	in, err := r.exchangeDNS("udp", nil)(ctx, msg, nameserver)
+	if err == nil && in.Truncated {
+		return r.exchangeDNS("tcp", nil)(ctx, msg, nameserver)
+	}
+	return in, err
+}
+
+func (r *DNSResolver) exchangeDNS(network string, tlsConfig *tls.Config) exchangeFunc {
+	return func(ctx context.Context, msg *dns.Msg, nameserver string) (*dns.Msg, error) {
+		// dns.Client.ExchangeContext mutates the client, so each query gets
+		// its own and the context deadline becomes its timeout.
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		timeout := r.timeout
+		if deadline, ok := ctx.Deadline(); ok {
+			timeout = time.Until(deadline)
+		}
+		client := &dns.Client{Net: network, TLSConfig: tlsConfig, Timeout: timeout}
+		in, _, err := client.Exchange(msg, nameserver)
+		return in, err
+	}
+}
+
+// exchangeHTTPS sends the query as a DNS-over-HTTPS POST, as in RFC 8484.
+func exchangeHTTPS(ctx context.Context, client *http.Client, msg *dns.Msg, url string) (*dns.Msg, error) {
+	// The ID is zeroed so that responses are cacheable.
+	query := msg.Copy()
+	query.Id = 0
+	packed, err := query.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(packed))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected HTTP status: %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, dns.MaxMsgSize))
+	if err != nil {
+		return nil, err
+	}
+
+	in := new(dns.Msg)
+	if err := in.Unpack(body); err != nil {
+		return nil, err
+	}
+	in.Id = msg.Id
+	return in, nil
+}
+
+func negativeTTL(msg *dns.Msg) time.Duration {
+	for _, rr := range msg.Ns {
+		if soa, ok := rr.(*dns.SOA); ok {
+			ttl := soa.Minttl
+			if soa.Hdr.Ttl < ttl {
+				ttl = soa.Hdr.Ttl
+			}
+			return time.Duration(ttl) * time.Second
+		}
+	}
+	return 0
+}
+
+// minTTL returns the lower of two TTLs, where zero is unknown.
+func minTTL(left, right time.Duration) time.Duration {
+	if left == 0 || (right != 0 && right < left) {
+		return right
+	}
+	return left
+}
@@ -0,0 +1,345 @@
+package srvrecord
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/require"
+)
+
+func testDNSResponse(req *dns.Msg, overUDP bool) *dns.Msg {
+	resp := new(dns.Msg)
+	resp.SetReply(req)
+
+	question := req.Question[0]
+	header := func(rrtype uint16, ttl uint32) dns.RR_Header {
+		return dns.RR_Header{Name: question.Name, Rrtype: rrtype, Class: dns.ClassINET, Ttl: ttl}
+	}
+	soa := &dns.SOA{
+		Hdr:    dns.RR_Header{Name: "example.com.", Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: 300},
+		Ns:     "ns.example.com.",
+		Mbox:   "admin.example.com.",
+		Minttl: 15,
+	}
+
+	switch {
+	case question.Name == "_grpc._tcp.example.com." && question.Qtype == dns.TypeSRV:
+		resp.Answer = []dns.RR{
+			&dns.SRV{Hdr: header(dns.TypeSRV, 60), Priority: 10, Weight: 1, Port: 50051, Target: "host1.example.com."},
+			&dns.SRV{Hdr: header(dns.TypeSRV, 30), Priority: 20, Weight: 5, Port: 50051, Target: "host2.example.com."},
+		}
+	case question.Name == "_grpc._tcp.truncated.example.com." && question.Qtype == dns.TypeSRV:
+		if overUDP {
+			resp.Truncated = true
+			break
+		}
+		resp.Answer = []dns.RR{
+			&dns.SRV{Hdr: header(dns.TypeSRV, 60), Priority: 10, Weight: 1, Port: 50051, Target: "host1.example.com."},
+		}
+	case question.Name == "host1.example.com." && question.Qtype == dns.TypeA:
+		resp.Answer = []dns.RR{&dns.A{Hdr: header(dns.TypeA, 20), A: net.ParseIP("10.0.0.1")}}
+	case question.Name == "host1.example.com." && question.Qtype == dns.TypeAAAA:
+		resp.Answer = []dns.RR{&dns.AAAA{Hdr: header(dns.TypeAAAA, 40), AAAA: net.ParseIP("fd00::1")}}
+	case question.Name == "v4only.example.com." && question.Qtype == dns.TypeA:
+		resp.Answer = []dns.RR{&dns.A{Hdr: header(dns.TypeA, 20), A: net.ParseIP("10.0.0.2")}}
+	case question.Name == "v4only.example.com.":
+		resp.Ns = []dns.RR{soa}
+	case question.Name == "_grpc._tcp.broken.example.com.":
+		resp.Rcode = dns.RcodeServerFailure
+	default:
+		resp.Rcode = dns.RcodeNameError
+		resp.Ns = []dns.RR{soa}
+	}
+
+	return resp
+}
+
+var testDNSHandler = dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+	_, overUDP := w.RemoteAddr().(*net.UDPAddr)
+	_ = w.WriteMsg(testDNSResponse(req, overUDP))
+})
+
+func serveTestDNS(t *testing.T, server *dns.Server) {
+	server.Handler = testDNSHandler
+	go func() {
+		_ = server.ActivateAndServe()
+	}()
+	t.Cleanup(func() { _ = server.Shutdown() })
+}
+
+// startTestDNSServer serves testDNSResponse over UDP and TCP on the same port
+// and returns its address.
+func startTestDNSServer(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	conn, err := net.ListenPacket("udp", listener.Addr().String())
+	require.NoError(t, err)
+
+	serveTestDNS(t, &dns.Server{Listener: listener})
+	serveTestDNS(t, &dns.Server{PacketConn: conn})
+
+	return listener.Addr().String()
+}
+
+// startTestDoHServer serves testDNSResponse over HTTPS and returns its URL
+// along with a TLS config that trusts it.
+func startTestDoHServer(t *testing.T) (string, *tls.Config) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil || r.Header.Get("Content-Type") != "application/dns-message" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		req := new(dns.Msg)
+		if err := req.Unpack(body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		packed, err := testDNSResponse(req, false).Pack()
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/dns-message")
+		_, _ = w.Write(packed)
+	}))
+	t.Cleanup(server.Close)
+
+	return server.URL + "/dns-query", server.Client().Transport.(*http.Transport).TLSClientConfig.Clone()
+}
+
+// startTestDoTServer serves testDNSResponse over TLS, reusing the certificate
+// of an HTTPS test server, and returns its address along with a TLS config
+// that trusts it.
+func startTestDoTServer(t *testing.T) (string, *tls.Config) {
+	https := httptest.NewTLSServer(http.NotFoundHandler())
+	t.Cleanup(https.Close)
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: https.TLS.Certificates})
+	require.NoError(t, err)
+	serveTestDNS(t, &dns.Server{Listener: listener, Net: "tcp-tls"})
+
+	return listener.Addr().String(), https.Client().Transport.(*http.Transport).TLSClientConfig.Clone()
+}
+
+func newTestResolvers(t *testing.T) map[string]*DNSResolver {
+	resolvers := map[string]*DNSResolver{}
+
+	addr := startTestDNSServer(t)
+	dotAddr, dotConfig := startTestDoTServer(t)
+	dohURL, dohConfig := startTestDoHServer(t)
+	for transport, opts := range map[string]DNSResolverOptions{
+		TransportUDP:   {Nameservers: []string{addr}},
+		TransportTCP:   {Nameservers: []string{addr}, Transport: TransportTCP},
+		TransportTLS:   {Nameservers: []string{dotAddr}, Transport: TransportTLS, TLSConfig: dotConfig},
+		TransportHTTPS: {Nameservers: []string{dohURL}, Transport: TransportHTTPS, TLSConfig: dohConfig},
+	} {
+		resolver, err := NewDNSResolver(opts)
+		require.NoError(t, err)
+		resolvers[transport] = resolver
+	}
+
+	return resolvers
+}
+
+func TestDNSResolverLookupSRV(t *testing.T) {
+	testCases := []struct {
+		name             string
+		dnsName          string
+		expected         []*net.SRV
+		expectedTTL      time.Duration
+		expectedNotFound bool
+		expectedErr      bool
+	}{
+		{
+			"records",
+			"example.com",
+			[]*net.SRV{
+				{Target: "host1.example.com.", Port: 50051, Priority: 10, Weight: 1},
+				{Target: "host2.example.com.", Port: 50051, Priority: 20, Weight: 5},
+			},
+			30 * time.Second,
+			false,
+			false,
+		},
+		{
+			"truncated",
+			"truncated.example.com",
+			[]*net.SRV{
+				{Target: "host1.example.com.", Port: 50051, Priority: 10, Weight: 1},
+			},
+			60 * time.Second,
+			false,
+			false,
+		},
+		{"nxdomain", "missing.example.com", nil, 15 * time.Second, true, true},
+		{"servfail", "broken.example.com", nil, 0, false, true},
+	}
+
+	for transport, resolver := range newTestResolvers(t) {
+		resolver := resolver
+		for _, tc := range testCases {
+			tc := tc
+			t.Run(transport+"/"+tc.name, func(t *testing.T) {
+				require := require.New(t)
+
+				addrs, ttl, err := resolver.LookupSRV(context.Background(), "grpc", "tcp", tc.dnsName)
+				require.Equal(tc.expectedTTL, ttl)
+				require.Equal(tc.expectedNotFound, isNotFound(err))
+				if tc.expectedErr {
+					require.Error(err)
+					return
+				}
+				require.NoError(err)
+				require.Equal(tc.expected, addrs)
+			})
+		}
+	}
+}
+
+func TestDNSResolverLookupHost(t *testing.T) {
+	resolver, err := NewDNSResolver(DNSResolverOptions{Nameservers: []string{startTestDNSServer(t)}})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name             string
+		host             string
+		expected         []string
+		expectedTTL      time.Duration
+		expectedNotFound bool
+	}{
+		{"dual stack", "host1.example.com.", []string{"10.0.0.1", "fd00::1"}, 20 * time.Second, false},
+		{"no ipv6", "v4only.example.com.", []string{"10.0.0.2"}, 15 * time.Second, false},
+		{"nxdomain", "missing.example.com.", nil, 15 * time.Second, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			addrs, ttl, err := resolver.LookupHost(context.Background(), tc.host)
+			require.Equal(tc.expectedTTL, ttl)
+			require.Equal(tc.expectedNotFound, isNotFound(err))
+			if !tc.expectedNotFound {
+				require.NoError(err)
+			}
+			require.Equal(tc.expected, addrs)
+		})
+	}
+}
+
+func TestDNSResolverFailover(t *testing.T) {
+	require := require.New(t)
+
+	// A nameserver that never answers.
+	silent, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(err)
+	defer silent.Close()
+
+	resolver, err := NewDNSResolver(DNSResolverOptions{
+		Nameservers: []string{silent.LocalAddr().String(), startTestDNSServer(t)},
+		Timeout:     50 * time.Millisecond,
+	})
+	require.NoError(err)
+
+	addrs, _, err := resolver.LookupSRV(context.Background(), "grpc", "tcp", "example.com")
+	require.NoError(err)
+	require.Len(addrs, 2)
+
+	resolver, err = NewDNSResolver(DNSResolverOptions{
+		Nameservers: []string{silent.LocalAddr().String()},
+		Timeout:     50 * time.Millisecond,
+	})
+	require.NoError(err)
+
+	_, _, err = resolver.LookupSRV(context.Background(), "grpc", "tcp", "example.com")
+	var dnsErr *net.DNSError
+	require.ErrorAs(err, &dnsErr)
+	require.True(dnsErr.IsTimeout)
+	require.False(dnsErr.IsNotFound)
+}
+
+func TestNewDNSResolver(t *testing.T) {
+	testCases := []struct {
+		name                string
+		opts                DNSResolverOptions
+		expectedNameservers []string
+		expectedErr         bool
+	}{
+		{"no nameservers", DNSResolverOptions{}, nil, true},
+		{"unknown transport", DNSResolverOptions{Nameservers: []string{"10.0.0.1"}, Transport: "quic"}, nil, true},
+		{
+			"default ports",
+			DNSResolverOptions{Nameservers: []string{"10.0.0.1", "10.0.0.2:5353", "fd00::1"}},
+			[]string{"10.0.0.1:53", "10.0.0.2:5353", "[fd00::1]:53"},
+			false,
+		},
+		{
+			"tls port",
+			DNSResolverOptions{Nameservers: []string{"10.0.0.1"}, Transport: TransportTLS},
+			[]string{"10.0.0.1:853"},
+			false,
+		},
+		{
+			"https urls",
+			DNSResolverOptions{Nameservers: []string{"https://dns.example.com/dns-query"}, Transport: TransportHTTPS},
+			[]string{"https://dns.example.com/dns-query"},
+			false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			resolver, err := NewDNSResolver(tc.opts)
+			if tc.expectedErr {
+				require.Error(err)
+				return
+			}
+			require.NoError(err)
+			require.Equal(tc.expectedNameservers, resolver.nameservers)
+		})
+	}
+}
+
+func TestTTLScheduleDelay(t *testing.T) {
+	testCases := []struct {
+		name     string
+		schedule TTLSchedule
+		ttl      time.Duration
+		expected time.Duration
+	}{
+		{"disabled", TTLSchedule{}, 30 * time.Second, 1 * time.Second},
+		{"unknown ttl", TTLSchedule{Enabled: true}, 0, 1 * time.Second},
+		{"ttl", TTLSchedule{Enabled: true, Min: 5 * time.Second, Max: 5 * time.Minute}, 30 * time.Second, 30 * time.Second},
+		{"below minimum", TTLSchedule{Enabled: true, Min: 5 * time.Second, Max: 5 * time.Minute}, 1 * time.Second, 5 * time.Second},
+		{"above maximum", TTLSchedule{Enabled: true, Min: 5 * time.Second, Max: 5 * time.Minute}, 1 * time.Hour, 5 * time.Minute},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.New(t).Equal(tc.expected, tc.schedule.delay(tc.ttl, 1*time.Second))
+		})
+	}
+
+	t.Run("jitter", func(t *testing.T) {
+		require := require.New(t)
+
+		schedule := TTLSchedule{Enabled: true, Jitter: 0.1}
+		for i := 0; i < 100; i++ {
+			delay := schedule.delay(30*time.Second, 1*time.Second)
+			require.GreaterOrEqual(int64(delay), int64(27*time.Second))
+			require.LessOrEqual(int64(delay), int64(33*time.Second))
+		}
+	})
+}
@@ -2,10 +2,13 @@ package srvrecord
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math/rand"
 	"net"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/rs/zerolog/log"
@@ -15,57 +18,301 @@ import (
 	"github.com/authzed/servok/internal/sources"
 )
 
-type resolverFunc func() ([]*net.SRV, error)
+type resolverFunc func() ([]*net.SRV, time.Duration, error)
 
-func NewSrvRecordSource(shutdownCtx context.Context, service, proto, name string, updatePeriod time.Duration) (sources.Endpoint, error) {
-	resolver := func() ([]*net.SRV, error) {
-		_, addrs, err := net.LookupSRV(service, proto, name)
-		return addrs, err
+type hostResolverFunc func(host string) ([]string, time.Duration, error)
+
+// Kind serves WatchRequest.SRVRequest by periodically resolving DNS SRV records.
+type Kind struct {
+	// UpdatePeriod is used for requests that don't specify a poll interval.
+	UpdatePeriod time.Duration
+
+	// MinUpdatePeriod and MaxUpdatePeriod bound the poll intervals that
+	// requests may specify, when set. They should always be set for
+	// untrusted clients, which could otherwise poll as often as they like.
+	MinUpdatePeriod time.Duration
+	MaxUpdatePeriod time.Duration
+
+	Backoff Backoff
+
+	// Resolver performs the lookups, defaulting to the SystemResolver.
+	Resolver Resolver
+
+	// TTL schedules lookups by the TTLs of their answers, when enabled.
+	TTL TTLSchedule
+
+	periods    sync.Map // sources.Endpoint -> *pollPeriod
+	lookupErrs sources.ErrorReporter
+}
+
+// TTLSchedule re-resolves records when their TTL expires rather than at a
+// fixed poll interval. Lookups whose TTL is unknown, such as those made by
+// the SystemResolver, fall back to the poll interval.
+type TTLSchedule struct {
+	Enabled bool
+
+	// Min and Max bound how soon and how late the next lookup happens.
+	Min time.Duration
+	Max time.Duration
+
+	// Jitter randomly varies each delay by up to this fraction of it, so
+	// that records with the same TTL are not all re-resolved at once.
+	Jitter float64
+}
+
+// Validate returns an error unless Min is at most Max, when Max is set, and
+// Jitter is at least zero and below one, as larger jitter could schedule
+// lookups immediately.
+func (s TTLSchedule) Validate() error {
+	if s.Min < 0 || s.Max < 0 {
+		return fmt.Errorf("TTL bounds must not be negative, got min %v and max %v", s.Min, s.Max)
+	}
+	if s.Max > 0 && s.Min > s.Max {
+		return fmt.Errorf("min TTL %v is longer than the max TTL %v", s.Min, s.Max)
 	}
+	if s.Jitter < 0 || s.Jitter >= 1 {
+		return fmt.Errorf("TTL jitter must be at least 0 and below 1, got %v", s.Jitter)
+	}
+	return nil
+}
 
-	_, err := resolver()
-	if err != nil {
-		return nil, err
+func (s TTLSchedule) delay(ttl, period time.Duration) time.Duration {
+	if !s.Enabled || ttl <= 0 {
+		return period
+	}
+
+	delay := ttl
+	if delay < s.Min {
+		delay = s.Min
+	}
+	if s.Max > 0 && delay > s.Max {
+		delay = s.Max
+	}
+	if s.Jitter > 0 {
+		delay += time.Duration((rand.Float64()*2 - 1) * s.Jitter * float64(delay))
+	}
+	return delay
+}
+
+// Backoff configures how quickly failed lookups are retried. The delay starts
+// at Initial and doubles after every consecutive failure, up to Max.
+type Backoff struct {
+	Initial time.Duration
+	Max     time.Duration
+}
+
+// Validate returns an error unless both delays are positive and Max is at
+// least Initial, as failed lookups would otherwise be retried without delay.
+func (b Backoff) Validate() error {
+	if b.Initial <= 0 || b.Max <= 0 {
+		return fmt.Errorf("retry backoff must be positive, got initial %v and max %v", b.Initial, b.Max)
+	}
+	if b.Max < b.Initial {
+		return fmt.Errorf("max retry backoff %v is shorter than the initial backoff %v", b.Max, b.Initial)
+	}
+	return nil
+}
+
+// ValidatePollIntervals returns an error unless the poll intervals are all
+// positive and UpdatePeriod is within MinUpdatePeriod and MaxUpdatePeriod.
+func (k *Kind) ValidatePollIntervals() error {
+	if k.UpdatePeriod <= 0 || k.MinUpdatePeriod <= 0 || k.MaxUpdatePeriod <= 0 {
+		return fmt.Errorf("poll intervals must be positive, got %v, min %v and max %v", k.UpdatePeriod, k.MinUpdatePeriod, k.MaxUpdatePeriod)
+	}
+	if k.UpdatePeriod < k.MinUpdatePeriod || k.UpdatePeriod > k.MaxUpdatePeriod {
+		return fmt.Errorf("poll interval %v is not between min %v and max %v", k.UpdatePeriod, k.MinUpdatePeriod, k.MaxUpdatePeriod)
+	}
+	return nil
+}
+
+func (b Backoff) delay(failures int) time.Duration {
+	delay := b.Initial
+	for i := 1; i < failures && delay < b.Max; i++ {
+		delay *= 2
+	}
+	if delay > b.Max {
+		delay = b.Max
+	}
+	return delay
+}
+
+func (k *Kind) Key(request *v1.WatchRequest) string {
+	srv := request.GetSrv()
+	key := QualifiedName(srv.Service, srv.Protocol, srv.DnsName)
+	if srv.PriorityTiers == v1.WatchRequest_SRVRequest_PRIORITY_TIERS_LOWEST {
+		key += "+lowest"
+	}
+	if srv.ResolveAddresses {
+		key += "+addresses"
+	}
+	return key
+}
+
+func (k *Kind) New(ctx context.Context, request *v1.WatchRequest) (sources.Endpoint, error) {
+	period := newPollPeriod(k.updatePeriod(request.GetSrv()))
+	errs := sources.NewLatestError()
+	source := k.newSource(ctx, k.Key(request), request.GetSrv(), period, errs)
+
+	k.periods.Store(source, period)
+	k.lookupErrs.Track(ctx, source, errs)
+	go func() {
+		<-ctx.Done()
+		k.periods.Delete(source)
+	}()
+
+	return source, nil
+}
+
+// Tune polls the source at the fastest interval requested by its clients.
+func (k *Kind) Tune(source sources.Endpoint, requests []*v1.WatchRequest) {
+	period, ok := k.periods.Load(source)
+	if !ok {
+		return
+	}
+
+	fastest := k.updatePeriod(nil)
+	for i, request := range requests {
+		requested := k.updatePeriod(request.GetSrv())
+		if i == 0 || requested < fastest {
+			fastest = requested
+		}
+	}
+	period.(*pollPeriod).set(fastest)
+}
+
+// Errors reports the failed lookups of the source, during which it keeps
+// serving its last known endpoints.
+func (k *Kind) Errors(source sources.Endpoint) <-chan error {
+	return k.lookupErrs.Errors(source)
+}
+
+// updatePeriod returns the poll interval for the request, clamped to the
+// configured bounds.
+func (k *Kind) updatePeriod(request *v1.WatchRequest_SRVRequest) time.Duration {
+	period := k.UpdatePeriod
+	if request.GetPollInterval() != nil {
+		period = request.GetPollInterval().AsDuration()
+	}
+	if k.MinUpdatePeriod > 0 && period < k.MinUpdatePeriod {
+		period = k.MinUpdatePeriod
+	}
+	if k.MaxUpdatePeriod > 0 && period > k.MaxUpdatePeriod {
+		period = k.MaxUpdatePeriod
+	}
+	return period
+}
+
+// QualifiedName returns the name of the SRV record that is looked up for the
+// service, protocol and domain name, as in RFC 2782.
+func QualifiedName(service, proto, name string) string {
+	return fmt.Sprintf("_%s._%s.%s", service, proto, name)
+}
+
+// newSource starts resolving the records without waiting for the first
+// lookup. Every failed lookup may succeed once it is retried, since names are
+// validated with the request, so they are reported through errs rather than
+// failing the source.
+func (k *Kind) newSource(ctx context.Context, key string, request *v1.WatchRequest_SRVRequest, period *pollPeriod, errs *sources.LatestError) sources.Endpoint {
+	dnsResolver := k.Resolver
+	if dnsResolver == nil {
+		dnsResolver = SystemResolver{}
+	}
+
+	service, proto, name := request.Service, request.Protocol, request.DnsName
+	resolver := func() ([]*net.SRV, time.Duration, error) {
+		start := time.Now()
+		addrs, ttl, err := dnsResolver.LookupSRV(ctx, service, proto, name)
+		observeLookup(key, recordTypeSRV, start, err)
+		if request.PriorityTiers == v1.WatchRequest_SRVRequest_PRIORITY_TIERS_LOWEST {
+			addrs = lowestPriority(addrs)
+		}
+		return addrs, ttl, err
+	}
+
+	var hostResolver hostResolverFunc
+	if request.ResolveAddresses {
+		hostResolver = func(host string) ([]string, time.Duration, error) {
+			start := time.Now()
+			addresses, ttl, err := dnsResolver.LookupHost(ctx, host)
+			observeLookup(key, recordTypeHost, start, err)
+			return addresses, ttl, err
+		}
 	}
 
 	updateChan := make(chan []*v1.Endpoint)
 
-	log.Info().Stringer("period", updatePeriod).Str("service", service).Str("proto", proto).Str("name", name).Msg("starting DNS SRV endpoint source")
-	go run(shutdownCtx, updateChan, resolver, updatePeriod)
+	log.Info().Stringer("period", period.get()).Bool("ttl", k.TTL.Enabled).Str("service", service).Str("proto", proto).Str("name", name).Msg("starting DNS SRV endpoint source")
+	go func() {
+		run(ctx, updateChan, resolver, hostResolver, period, errs, k.TTL, k.Backoff)
+		deleteSourceMetrics(key)
+	}()
 
-	return updateChan, nil
+	return updateChan
 }
 
 func run(ctx context.Context,
 	updates chan<- []*v1.Endpoint,
 	resolver resolverFunc,
-	updatePeriod time.Duration) {
+	hostResolver hostResolverFunc,
+	period *pollPeriod,
+	errs *sources.LatestError,
+	schedule TTLSchedule,
+	backoff Backoff) {
 
 	defer close(updates)
 
-	ticker := time.NewTicker(updatePeriod)
+	// The first lookup happens immediately, and later ones each period.
+	timer := time.NewTimer(0)
+	defer timer.Stop()
 
 	stop := false
+	first := true
+	failures := 0
 	last := &v1.WatchResponse{Endpoints: []*v1.Endpoint{{Hostname: "bootstrap"}}}
 	for !stop {
 		select {
 		case <-ctx.Done():
 			stop = true
-		case <-ticker.C:
-			addrs, err := resolver()
+		case <-period.changed:
+			// The first lookup and retries that are backing off keep their
+			// schedule.
+			if !first && failures == 0 {
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+				timer.Reset(period.get())
+			}
+		case <-timer.C:
+			first = false
+			endpoints, ttl, err := lookup(resolver, hostResolver)
 			if err != nil {
-				log.Error().Err(err).Msg("error resolving DNS SRV endpoints")
-				stop = true
+				failures++
+				delay := backoff.delay(failures)
+				log.Warn().Err(err).Int("failures", failures).Stringer("retryIn", delay).Msg("error resolving DNS SRV endpoints, serving last known endpoints")
+				errs.Set(err)
+				timer.Reset(delay)
 				break
 			}
-			endpoints := rewriteAndSortAddrs(addrs)
+			if failures > 0 {
+				log.Info().Int("failures", failures).Msg("recovered resolving DNS SRV endpoints")
+				errs.Set(nil)
+				failures = 0
+			}
+			timer.Reset(schedule.delay(ttl, period.get()))
 
 			next := &v1.WatchResponse{Endpoints: endpoints}
 
 			if !proto.Equal(last, next) {
 				numEntries := len(endpoints)
 				log.Debug().Int("numEntries", numEntries).Msg("writing DNS SRV updates to the channel")
-				updates <- endpoints
+				select {
+				case updates <- endpoints:
+				case <-ctx.Done():
+					stop = true
+				}
 				log.Debug().Int("numEntries", numEntries).Msg("DNS SRV updates written")
 			}
 			last = next
@@ -75,6 +322,36 @@ func run(ctx context.Context,
 	log.Info().Msg("stopping DNS SRV endpoint source")
 }
 
+// lookup resolves the current set of endpoints and the lowest TTL among the
+// answers. A name that does not exist resolves to an empty set, while any
+// other failure returns an error.
+func lookup(resolver resolverFunc, hostResolver hostResolverFunc) ([]*v1.Endpoint, time.Duration, error) {
+	addrs, ttl, err := resolver()
+	if isNotFound(err) {
+		addrs, err = nil, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	endpoints := rewriteAndSortAddrs(addrs)
+	if hostResolver != nil {
+		addressTTL, err := resolveAddresses(endpoints, hostResolver)
+		if err != nil {
+			return nil, 0, err
+		}
+		ttl = minTTL(ttl, addressTTL)
+	}
+	return endpoints, ttl, nil
+}
+
+// isNotFound returns true for errors that indicate the name does not exist
+// (NXDOMAIN), as opposed to a failure to get an answer.
+func isNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
+
 func rewriteAndSortAddrs(addrs []*net.SRV) []*v1.Endpoint {
 	var resolved []*v1.Endpoint
 	for _, addr := range addrs {
@@ -82,6 +359,7 @@ func rewriteAndSortAddrs(addrs []*net.SRV) []*v1.Endpoint {
 			Hostname: addr.Target,
 			Port:     uint32(addr.Port),
 			Weight:   uint32(addr.Weight),
+			Priority: uint32(addr.Priority),
 		}
 
 		resolved = append(resolved, endpoint)
@@ -95,6 +373,59 @@ func rewriteAndSortAddrs(addrs []*net.SRV) []*v1.Endpoint {
 	return resolved
 }
 
+// resolveAddresses populates the IP addresses of every endpoint, looking up
+// each distinct hostname once, and returns the lowest TTL among the answers.
+func resolveAddresses(endpoints []*v1.Endpoint, hostResolver hostResolverFunc) (time.Duration, error) {
+	var lowestTTL time.Duration
+	resolved := map[string][]string{}
+	for _, endpoint := range endpoints {
+		addresses, ok := resolved[endpoint.Hostname]
+		if !ok {
+			var err error
+			var ttl time.Duration
+			addresses, ttl, err = hostResolver(endpoint.Hostname)
+			if isNotFound(err) {
+				log.Warn().Str("hostname", endpoint.Hostname).Msg("DNS SRV target has no addresses")
+				addresses, err = nil, nil
+			}
+			if err != nil {
+				return 0, err
+			}
+			lowestTTL = minTTL(lowestTTL, ttl)
+
+			addresses = append([]string(nil), addresses...)
+			sort.Strings(addresses)
+			resolved[endpoint.Hostname] = addresses
+		}
+		endpoint.Addresses = addresses
+	}
+	return lowestTTL, nil
+}
+
+// lowestPriority filters the records down to those in the most preferred
+// priority tier.
+func lowestPriority(addrs []*net.SRV) []*net.SRV {
+	if len(addrs) == 0 {
+		return addrs
+	}
+
+	lowest := addrs[0].Priority
+	for _, addr := range addrs {
+		if addr.Priority < lowest {
+			lowest = addr.Priority
+		}
+	}
+
+	filtered := make([]*net.SRV, 0, len(addrs))
+	for _, addr := range addrs {
+		if addr.Priority == lowest {
+			filtered = append(filtered, addr)
+		}
+	}
+	return filtered
+}
+
 func canonicalSRV(endpoint *v1.Endpoint) string {
-	return fmt.Sprintf("0 %d %d %s", endpoint.Weight, endpoint.Port, endpoint.Hostname)
+	// Numbers are zero padded so that they sort numerically.
+	return fmt.Sprintf("%05d %05d %05d %s", endpoint.Priority, endpoint.Weight, endpoint.Port, endpoint.Hostname)
 }
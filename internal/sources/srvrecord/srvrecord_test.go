@@ -3,17 +3,23 @@ package srvrecord
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/durationpb"
 
 	v1 "github.com/authzed/servok/internal/proto/servok/api/v1"
+	"github.com/authzed/servok/internal/sources"
 )
 
+var testBackoff = Backoff{Initial: 500 * time.Microsecond, Max: 2 * time.Millisecond}
+
 func TestRewriteAddrs(t *testing.T) {
 	testCases := []struct {
 		name     string
@@ -55,6 +61,19 @@ func TestRewriteAddrs(t *testing.T) {
 				{Hostname: "host2", Port: 50051, Weight: 5},
 			},
 		},
+		{
+			"priority order",
+			[]*net.SRV{
+				{Target: "host1", Port: 50051, Priority: 10, Weight: 1},
+				{Target: "host2", Port: 50051, Priority: 2, Weight: 5},
+				{Target: "host3", Port: 50051, Priority: 2, Weight: 1},
+			},
+			[]*v1.Endpoint{
+				{Hostname: "host3", Port: 50051, Priority: 2, Weight: 1},
+				{Hostname: "host2", Port: 50051, Priority: 2, Weight: 5},
+				{Hostname: "host1", Port: 50051, Priority: 10, Weight: 1},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -69,6 +88,45 @@ func TestRewriteAddrs(t *testing.T) {
 	}
 }
 
+func TestLowestPriority(t *testing.T) {
+	testCases := []struct {
+		name     string
+		addrs    []*net.SRV
+		expected []*net.SRV
+	}{
+		{"empty", nil, nil},
+		{
+			"single tier",
+			[]*net.SRV{
+				{Target: "host1", Port: 50051, Priority: 1, Weight: 1},
+				{Target: "host2", Port: 50051, Priority: 1, Weight: 1},
+			},
+			[]*net.SRV{
+				{Target: "host1", Port: 50051, Priority: 1, Weight: 1},
+				{Target: "host2", Port: 50051, Priority: 1, Weight: 1},
+			},
+		},
+		{
+			"multiple tiers",
+			[]*net.SRV{
+				{Target: "standby", Port: 50051, Priority: 20, Weight: 1},
+				{Target: "primary1", Port: 50051, Priority: 10, Weight: 1},
+				{Target: "primary2", Port: 50051, Priority: 10, Weight: 5},
+			},
+			[]*net.SRV{
+				{Target: "primary1", Port: 50051, Priority: 10, Weight: 1},
+				{Target: "primary2", Port: 50051, Priority: 10, Weight: 5},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.New(t).Equal(tc.expected, lowestPriority(tc.addrs))
+		})
+	}
+}
+
 func TestRun(t *testing.T) {
 	testCases := []struct {
 		name                 string
@@ -113,6 +171,7 @@ func TestRun(t *testing.T) {
 			[]int{2, 1},
 		},
 		{"resolver error", nil, errors.New("resolver error!"), []int{}},
+		{"not found", nil, &net.DNSError{Err: "no such host", IsNotFound: true}, []int{0}},
 	}
 
 	for _, tc := range testCases {
@@ -123,25 +182,25 @@ func TestRun(t *testing.T) {
 			updateChan := make(chan []*v1.Endpoint)
 
 			var index int
-			fakeResolver := func() ([]*net.SRV, error) {
+			fakeResolver := func() ([]*net.SRV, time.Duration, error) {
 				if tc.resolverErr != nil {
-					return nil, tc.resolverErr
+					return nil, 0, tc.resolverErr
 				}
 
 				scriptLen := len(tc.resolverAddrs)
 				if scriptLen > 0 {
 					if index >= scriptLen {
-						return tc.resolverAddrs[scriptLen-1], nil
+						return tc.resolverAddrs[scriptLen-1], 0, nil
 					}
 					index += 1
-					return tc.resolverAddrs[index-1], nil
+					return tc.resolverAddrs[index-1], 0, nil
 				}
-				return nil, nil
+				return nil, 0, nil
 			}
 
 			var exited bool
 			go func() {
-				run(ctx, updateChan, fakeResolver, 500*time.Microsecond)
+				run(ctx, updateChan, fakeResolver, nil, newPollPeriod(500*time.Microsecond), nil, TTLSchedule{}, testBackoff)
 				exited = true
 			}()
 
@@ -161,10 +220,11 @@ func TestRun(t *testing.T) {
 				}, 100*time.Millisecond, 1*time.Millisecond)
 			}
 
-			if tc.resolverErr != nil {
-				update, ok := <-updateChan
-				require.False(ok)
-				require.Nil(update)
+			// Errors keep the source running without emitting any updates.
+			select {
+			case <-updateChan:
+				require.Fail("unexpected update")
+			case <-time.After(5 * time.Millisecond):
 			}
 
 			cancel()
@@ -181,3 +241,424 @@ func TestRun(t *testing.T) {
 		})
 	}
 }
+
+func TestResolveAddresses(t *testing.T) {
+	hosts := map[string][]string{
+		"host1": {"10.0.0.2", "10.0.0.1", "fd00::1"},
+		"host2": {"10.0.0.3"},
+	}
+	fakeHostResolver := func(host string) ([]string, time.Duration, error) {
+		if host == "broken" {
+			return nil, 0, errors.New("resolver error!")
+		}
+		addresses, ok := hosts[host]
+		if !ok {
+			return nil, 0, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+		}
+		return addresses, 0, nil
+	}
+
+	testCases := []struct {
+		name        string
+		endpoints   []*v1.Endpoint
+		expected    []*v1.Endpoint
+		expectedErr bool
+	}{
+		{
+			"multiple hosts",
+			[]*v1.Endpoint{
+				{Hostname: "host1", Port: 50051},
+				{Hostname: "host1", Port: 50052},
+				{Hostname: "host2", Port: 50051},
+			},
+			[]*v1.Endpoint{
+				{Hostname: "host1", Port: 50051, Addresses: []string{"10.0.0.1", "10.0.0.2", "fd00::1"}},
+				{Hostname: "host1", Port: 50052, Addresses: []string{"10.0.0.1", "10.0.0.2", "fd00::1"}},
+				{Hostname: "host2", Port: 50051, Addresses: []string{"10.0.0.3"}},
+			},
+			false,
+		},
+		{
+			"unknown host",
+			[]*v1.Endpoint{{Hostname: "missing", Port: 50051}},
+			[]*v1.Endpoint{{Hostname: "missing", Port: 50051}},
+			false,
+		},
+		{"resolver error", []*v1.Endpoint{{Hostname: "broken", Port: 50051}}, nil, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			_, err := resolveAddresses(tc.endpoints, fakeHostResolver)
+			if tc.expectedErr {
+				require.Error(err)
+				return
+			}
+			require.NoError(err)
+
+			resolvedResponse := &v1.WatchResponse{Endpoints: tc.endpoints}
+			expectedResponse := &v1.WatchResponse{Endpoints: tc.expected}
+			require.Empty(cmp.Diff(expectedResponse, resolvedResponse, protocmp.Transform()))
+		})
+	}
+}
+
+func TestRunAddressChanges(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	updateChan := make(chan []*v1.Endpoint)
+
+	fakeResolver := func() ([]*net.SRV, time.Duration, error) {
+		return []*net.SRV{{Target: "host1", Port: 50051, Priority: 0, Weight: 1}}, 0, nil
+	}
+
+	script := [][]string{{"10.0.0.1"}, {"10.0.0.1"}, {"10.0.0.2"}}
+	var index int
+	fakeHostResolver := func(host string) ([]string, time.Duration, error) {
+		if index >= len(script) {
+			return script[len(script)-1], 0, nil
+		}
+		index++
+		return script[index-1], 0, nil
+	}
+
+	go run(ctx, updateChan, fakeResolver, fakeHostResolver, newPollPeriod(500*time.Microsecond), nil, TTLSchedule{}, testBackoff)
+
+	for _, expectedAddress := range []string{"10.0.0.1", "10.0.0.2"} {
+		select {
+		case update := <-updateChan:
+			require.Len(update, 1)
+			require.Equal([]string{expectedAddress}, update[0].Addresses)
+		case <-time.After(1 * time.Second):
+			require.Fail("timed out waiting for update")
+		}
+	}
+}
+
+func TestRunTransientErrors(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	updateChan := make(chan []*v1.Endpoint)
+
+	host1 := []*net.SRV{{Target: "host1", Port: 50051, Priority: 0, Weight: 1}}
+	host2 := []*net.SRV{{Target: "host2", Port: 50051, Priority: 0, Weight: 1}}
+	servfail := &net.DNSError{Err: "server misbehaving", IsTemporary: true}
+	nxdomain := &net.DNSError{Err: "no such host", IsNotFound: true}
+
+	script := []struct {
+		addrs []*net.SRV
+		err   error
+	}{
+		{host1, nil},
+		{nil, servfail},
+		{nil, servfail},
+		{host1, nil},
+		{nil, servfail},
+		{host2, nil},
+		{nil, nxdomain},
+	}
+	var index int
+	fakeResolver := func() ([]*net.SRV, time.Duration, error) {
+		if index >= len(script) {
+			index = len(script) - 1
+		}
+		index++
+		return script[index-1].addrs, 0, script[index-1].err
+	}
+
+	go run(ctx, updateChan, fakeResolver, nil, newPollPeriod(500*time.Microsecond), nil, TTLSchedule{}, testBackoff)
+
+	for _, expectedHostnames := range [][]string{{"host1"}, {"host2"}, nil} {
+		select {
+		case update, ok := <-updateChan:
+			require.True(ok)
+			var hostnames []string
+			for _, endpoint := range update {
+				hostnames = append(hostnames, endpoint.Hostname)
+			}
+			require.Equal(expectedHostnames, hostnames)
+		case <-time.After(1 * time.Second):
+			require.Fail("timed out waiting for update")
+		}
+	}
+}
+
+func TestRunReportsErrors(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	updateChan := make(chan []*v1.Endpoint, 1)
+	errs := sources.NewLatestError()
+
+	servfail := &net.DNSError{Err: "server misbehaving", IsTemporary: true}
+	results := make(chan error)
+	fakeResolver := func() ([]*net.SRV, time.Duration, error) {
+		select {
+		case err := <-results:
+			return []*net.SRV{{Target: "host1", Port: 50051}}, 0, err
+		case <-ctx.Done():
+			return nil, 0, ctx.Err()
+		}
+	}
+
+	go run(ctx, updateChan, fakeResolver, nil, newPollPeriod(500*time.Microsecond), errs, TTLSchedule{}, testBackoff)
+
+	expectErr := func(expected error) {
+		select {
+		case err := <-errs.Errors():
+			require.Equal(expected, err)
+		case <-time.After(1 * time.Second):
+			require.Fail("timed out waiting for error")
+		}
+	}
+
+	results <- nil
+	results <- servfail
+	expectErr(servfail)
+
+	// Only recoveries from failures are reported
+	results <- nil
+	expectErr(nil)
+	results <- nil
+	select {
+	case err := <-errs.Errors():
+		require.Failf("unexpected error report", "%v", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	backoff := Backoff{Initial: 1 * time.Second, Max: 10 * time.Second}
+
+	testCases := []struct {
+		failures int
+		expected time.Duration
+	}{
+		{1, 1 * time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 8 * time.Second},
+		{5, 10 * time.Second},
+		{100, 10 * time.Second},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprint(tc.failures), func(t *testing.T) {
+			require.New(t).Equal(tc.expected, backoff.delay(tc.failures))
+		})
+	}
+}
+
+func TestBackoffValidate(t *testing.T) {
+	testCases := []struct {
+		name        string
+		backoff     Backoff
+		expectedErr string
+	}{
+		{"valid", Backoff{Initial: 1 * time.Second, Max: 30 * time.Second}, ""},
+		{"constant", Backoff{Initial: 1 * time.Second, Max: 1 * time.Second}, ""},
+		{"zero initial", Backoff{Initial: 0, Max: 30 * time.Second}, "retry backoff must be positive, got initial 0s and max 30s"},
+		{"zero max", Backoff{Initial: 1 * time.Second, Max: 0}, "retry backoff must be positive, got initial 1s and max 0s"},
+		{"negative", Backoff{Initial: -1 * time.Second, Max: 30 * time.Second}, "retry backoff must be positive, got initial -1s and max 30s"},
+		{"max below initial", Backoff{Initial: 10 * time.Second, Max: 1 * time.Second}, "max retry backoff 1s is shorter than the initial backoff 10s"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.backoff.Validate()
+			if tc.expectedErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestTTLScheduleValidate(t *testing.T) {
+	testCases := []struct {
+		name        string
+		schedule    TTLSchedule
+		expectedErr string
+	}{
+		{"valid", TTLSchedule{Min: 1 * time.Second, Max: 5 * time.Minute, Jitter: 0.1}, ""},
+		{"unbounded", TTLSchedule{Min: 1 * time.Second}, ""},
+		{"no jitter", TTLSchedule{Min: 1 * time.Second, Max: 1 * time.Second}, ""},
+		{"negative min", TTLSchedule{Min: -1 * time.Second, Max: 5 * time.Minute}, "TTL bounds must not be negative, got min -1s and max 5m0s"},
+		{"min above max", TTLSchedule{Min: 10 * time.Minute, Max: 5 * time.Minute}, "min TTL 10m0s is longer than the max TTL 5m0s"},
+		{"negative jitter", TTLSchedule{Jitter: -0.1}, "TTL jitter must be at least 0 and below 1, got -0.1"},
+		{"full jitter", TTLSchedule{Jitter: 1}, "TTL jitter must be at least 0 and below 1, got 1"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.schedule.Validate()
+			if tc.expectedErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestValidatePollIntervals(t *testing.T) {
+	testCases := []struct {
+		name             string
+		period, min, max time.Duration
+		expectedErr      string
+	}{
+		{"valid", 1 * time.Second, 100 * time.Millisecond, 1 * time.Minute, ""},
+		{"fixed", 1 * time.Second, 1 * time.Second, 1 * time.Second, ""},
+		{"zero period", 0, 100 * time.Millisecond, 1 * time.Minute, "poll intervals must be positive, got 0s, min 100ms and max 1m0s"},
+		{"zero min", 1 * time.Second, 0, 1 * time.Minute, "poll intervals must be positive, got 1s, min 0s and max 1m0s"},
+		{"negative max", 1 * time.Second, 100 * time.Millisecond, -1 * time.Minute, "poll intervals must be positive, got 1s, min 100ms and max -1m0s"},
+		{"below min", 10 * time.Millisecond, 100 * time.Millisecond, 1 * time.Minute, "poll interval 10ms is not between min 100ms and max 1m0s"},
+		{"above max", 1 * time.Hour, 100 * time.Millisecond, 1 * time.Minute, "poll interval 1h0m0s is not between min 100ms and max 1m0s"},
+		{"min above max", 1 * time.Second, 1 * time.Minute, 100 * time.Millisecond, "poll interval 1s is not between min 1m0s and max 100ms"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			kind := &Kind{UpdatePeriod: tc.period, MinUpdatePeriod: tc.min, MaxUpdatePeriod: tc.max}
+			err := kind.ValidatePollIntervals()
+			if tc.expectedErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestTune(t *testing.T) {
+	kind := &Kind{
+		UpdatePeriod:    10 * time.Second,
+		MinUpdatePeriod: 1 * time.Second,
+		MaxUpdatePeriod: 1 * time.Minute,
+	}
+
+	requestWithInterval := func(interval time.Duration) *v1.WatchRequest {
+		srv := &v1.WatchRequest_SRVRequest{Service: "grpc", Protocol: "tcp", DnsName: "example.com"}
+		if interval > 0 {
+			srv.PollInterval = durationpb.New(interval)
+		}
+		return &v1.WatchRequest{RequestTypeOneof: &v1.WatchRequest_Srv{Srv: srv}}
+	}
+
+	testCases := []struct {
+		name      string
+		intervals []time.Duration
+		expected  time.Duration
+	}{
+		{"no clients", nil, 10 * time.Second},
+		{"default", []time.Duration{0}, 10 * time.Second},
+		{"requested", []time.Duration{5 * time.Second}, 5 * time.Second},
+		{"fastest", []time.Duration{0, 5 * time.Second, 2 * time.Second}, 2 * time.Second},
+		{"clamped to minimum", []time.Duration{1 * time.Millisecond}, 1 * time.Second},
+		{"clamped to maximum", []time.Duration{1 * time.Hour}, 1 * time.Minute},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			source := make(chan []*v1.Endpoint)
+			period := newPollPeriod(30 * time.Second)
+			kind.periods.Store(sources.Endpoint(source), period)
+			defer kind.periods.Delete(sources.Endpoint(source))
+
+			var requests []*v1.WatchRequest
+			for _, interval := range tc.intervals {
+				requests = append(requests, requestWithInterval(interval))
+			}
+
+			kind.Tune(source, requests)
+			require.Equal(tc.expected, period.get())
+		})
+	}
+}
+
+func TestRunPeriodChange(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	updateChan := make(chan []*v1.Endpoint)
+
+	var lookups int32
+	fakeResolver := func() ([]*net.SRV, time.Duration, error) {
+		// Every lookup resolves a different host, so that each is emitted.
+		n := atomic.AddInt32(&lookups, 1)
+		return []*net.SRV{{Target: fmt.Sprintf("host%d", n), Port: 50051, Priority: 0, Weight: 1}}, 0, nil
+	}
+
+	period := newPollPeriod(1 * time.Hour)
+	go run(ctx, updateChan, fakeResolver, nil, period, nil, TTLSchedule{}, testBackoff)
+
+	// The first lookup doesn't wait for the period, unlike the next one.
+	expectHost := func(hostname string) {
+		select {
+		case update := <-updateChan:
+			require.Len(update, 1)
+			require.Equal(hostname, update[0].Hostname)
+		case <-time.After(1 * time.Second):
+			require.Fail("timed out waiting for update")
+		}
+	}
+	expectHost("host1")
+	select {
+	case <-updateChan:
+		require.Fail("unexpected update before the next poll")
+	case <-time.After(5 * time.Millisecond):
+	}
+
+	period.set(500 * time.Microsecond)
+	expectHost("host2")
+}
+
+func TestNewReportsLookupErrors(t *testing.T) {
+	require := require.New(t)
+
+	servfail := &net.DNSError{Err: "server misbehaving", IsTemporary: true}
+	resolver := &switchableResolver{err: servfail}
+	kind := &Kind{
+		UpdatePeriod: 1 * time.Hour,
+		Backoff:      Backoff{Initial: 1 * time.Millisecond, Max: 1 * time.Millisecond},
+		Resolver:     resolver,
+	}
+	request := &v1.WatchRequest{RequestTypeOneof: &v1.WatchRequest_Srv{Srv: &v1.WatchRequest_SRVRequest{
+		Service:  "grpc",
+		Protocol: "tcp",
+		DnsName:  "servfail.example.com",
+	}}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Failed lookups don't fail the source, which reports them instead
+	source, err := kind.New(ctx, request)
+	require.NoError(err)
+	select {
+	case err := <-kind.Errors(source):
+		require.Equal(servfail, err)
+	case <-time.After(1 * time.Second):
+		require.Fail("timed out waiting for error")
+	}
+
+	// And keeps retrying them until they succeed
+	resolver.setErr(nil)
+	select {
+	case update := <-source:
+		require.Len(update, 1)
+	case <-time.After(1 * time.Second):
+		require.Fail("timed out waiting for update")
+	}
+}
@@ -0,0 +1,41 @@
+package sources
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "github.com/authzed/servok/internal/proto/servok/api/v1"
+)
+
+// ParseTarget returns the WatchRequest named by a target path, which is one
+// of:
+//
+//	srv/<service>/<protocol>/<dns name>
+//	kubernetes/<namespace>/<service name>[/<port name>]
+//	file/<name>
+//
+// Targets name what to watch where a WatchRequest can't be used directly,
+// such as in gRPC dial targets and xDS resource names.
+func ParseTarget(target string) (*v1.WatchRequest, error) {
+	parts := strings.Split(target, "/")
+	switch {
+	case parts[0] == "srv" && len(parts) == 4:
+		return &v1.WatchRequest{RequestTypeOneof: &v1.WatchRequest_Srv{Srv: &v1.WatchRequest_SRVRequest{
+			Service:  parts[1],
+			Protocol: parts[2],
+			DnsName:  parts[3],
+		}}}, nil
+	case parts[0] == "kubernetes" && (len(parts) == 3 || len(parts) == 4):
+		kubernetes := &v1.WatchRequest_KubernetesRequest{Namespace: parts[1], ServiceName: parts[2]}
+		if len(parts) == 4 {
+			kubernetes.PortName = parts[3]
+		}
+		return &v1.WatchRequest{RequestTypeOneof: &v1.WatchRequest_Kubernetes{Kubernetes: kubernetes}}, nil
+	case parts[0] == "file" && len(parts) == 2:
+		return &v1.WatchRequest{RequestTypeOneof: &v1.WatchRequest_File{File: &v1.WatchRequest_FileRequest{
+			Name: parts[1],
+		}}}, nil
+	default:
+		return nil, fmt.Errorf("invalid target: %q", target)
+	}
+}
@@ -0,0 +1,63 @@
+package sources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	v1 "github.com/authzed/servok/internal/proto/servok/api/v1"
+)
+
+func TestParseTarget(t *testing.T) {
+	testCases := []struct {
+		name     string
+		target   string
+		expected *v1.WatchRequest
+	}{
+		{
+			"srv",
+			"srv/grpc/tcp/example.com",
+			&v1.WatchRequest{RequestTypeOneof: &v1.WatchRequest_Srv{Srv: &v1.WatchRequest_SRVRequest{
+				Service: "grpc", Protocol: "tcp", DnsName: "example.com",
+			}}},
+		},
+		{
+			"kubernetes",
+			"kubernetes/default/api",
+			&v1.WatchRequest{RequestTypeOneof: &v1.WatchRequest_Kubernetes{Kubernetes: &v1.WatchRequest_KubernetesRequest{
+				Namespace: "default", ServiceName: "api",
+			}}},
+		},
+		{
+			"kubernetes port",
+			"kubernetes/default/api/grpc",
+			&v1.WatchRequest{RequestTypeOneof: &v1.WatchRequest_Kubernetes{Kubernetes: &v1.WatchRequest_KubernetesRequest{
+				Namespace: "default", ServiceName: "api", PortName: "grpc",
+			}}},
+		},
+		{
+			"file",
+			"file/endpoints.yaml",
+			&v1.WatchRequest{RequestTypeOneof: &v1.WatchRequest_File{File: &v1.WatchRequest_FileRequest{
+				Name: "endpoints.yaml",
+			}}},
+		},
+		{"empty", "", nil},
+		{"unknown kind", "dns/example.com", nil},
+		{"missing parts", "srv/example.com", nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			request, err := ParseTarget(tc.target)
+			if tc.expected == nil {
+				require.Error(err)
+				return
+			}
+			require.NoError(err)
+			require.Equal(tc.expected.String(), request.String())
+		})
+	}
+}
@@ -0,0 +1,247 @@
+// Package balancer implements gRPC load balancing policies that honor the
+// weights and priorities of endpoints resolved by servok.
+//
+// Importing the package registers the policies, which are selected with a
+// service config such as:
+//
+//	{"loadBalancingConfig": [{"servok_weighted_round_robin": {}}]}
+//
+// Only the ready addresses with the lowest priority value are used, as in
+// RFC 2782. Within that tier requests are spread in proportion to weight;
+// addresses with a weight of zero only receive requests when every address in
+// the tier has a weight of zero. Addresses that were not resolved by servok
+// have a weight of one and a priority of zero.
+package balancer
+
+import (
+	"math/rand"
+	"sync"
+	"sync/atomic"
+
+	grpcbalancer "google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+
+	"github.com/authzed/servok/pkg/resolver"
+)
+
+const (
+	// WeightedRoundRobinName is the name of the policy that deterministically
+	// interleaves requests across addresses in proportion to their weights.
+	WeightedRoundRobinName = "servok_weighted_round_robin"
+
+	// LeastRequestName is the name of the policy that samples two addresses
+	// in proportion to their weights and picks the one with the fewest
+	// outstanding requests.
+	LeastRequestName = "servok_least_request"
+)
+
+func init() {
+	grpcbalancer.Register(&builder{name: WeightedRoundRobinName, newPicker: newWRRPicker})
+	grpcbalancer.Register(&builder{name: LeastRequestName, newPicker: newLeastRequestPicker})
+}
+
+type endpointWeight struct {
+	weight   uint32
+	priority uint32
+}
+
+// weightTable holds the latest weights of each address. It is read by
+// pickers on every pick, because the base balancer does not rebuild its
+// picker when only the attributes of an address change.
+type weightTable struct {
+	value atomic.Value // map[string]endpointWeight
+}
+
+func (t *weightTable) get(addr string) endpointWeight {
+	weights, _ := t.value.Load().(map[string]endpointWeight)
+	if weight, ok := weights[addr]; ok {
+		return weight
+	}
+	return endpointWeight{weight: 1}
+}
+
+type builder struct {
+	name      string
+	newPicker func(subConns []grpcbalancer.SubConn, addrs []string, weights *weightTable) grpcbalancer.Picker
+}
+
+func (b *builder) Name() string {
+	return b.name
+}
+
+func (b *builder) Build(cc grpcbalancer.ClientConn, opts grpcbalancer.BuildOptions) grpcbalancer.Balancer {
+	weights := &weightTable{}
+	pickerBuilder := &pickerBuilder{newPicker: b.newPicker, weights: weights}
+	return &weightedBalancer{
+		Balancer: base.NewBalancerBuilder(b.name, pickerBuilder, base.Config{HealthCheck: true}).Build(cc, opts),
+		weights:  weights,
+	}
+}
+
+// weightedBalancer records the weights of addresses before handing them to
+// the base balancer, which manages the SubConns.
+type weightedBalancer struct {
+	grpcbalancer.Balancer
+	weights *weightTable
+}
+
+func (b *weightedBalancer) UpdateClientConnState(state grpcbalancer.ClientConnState) error {
+	weights := make(map[string]endpointWeight, len(state.ResolverState.Addresses))
+	for _, addr := range state.ResolverState.Addresses {
+		weight, ok := resolver.Weight(addr)
+		if !ok {
+			weight = 1
+		}
+		priority, _ := resolver.Priority(addr)
+		weights[addr.Addr] = endpointWeight{weight: weight, priority: priority}
+	}
+	b.weights.value.Store(weights)
+
+	return b.Balancer.UpdateClientConnState(state)
+}
+
+type pickerBuilder struct {
+	newPicker func(subConns []grpcbalancer.SubConn, addrs []string, weights *weightTable) grpcbalancer.Picker
+	weights   *weightTable
+}
+
+func (b *pickerBuilder) Build(info base.PickerBuildInfo) grpcbalancer.Picker {
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPicker(grpcbalancer.ErrNoSubConnAvailable)
+	}
+
+	subConns := make([]grpcbalancer.SubConn, 0, len(info.ReadySCs))
+	addrs := make([]string, 0, len(info.ReadySCs))
+	for subConn, subConnInfo := range info.ReadySCs {
+		subConns = append(subConns, subConn)
+		addrs = append(addrs, subConnInfo.Address.Addr)
+	}
+	return b.newPicker(subConns, addrs, b.weights)
+}
+
+// effectiveWeights returns the weight with which each address should be
+// picked, which is zero for those outside of the lowest priority tier.
+func effectiveWeights(addrs []string, weights *weightTable) []int64 {
+	current := make([]endpointWeight, len(addrs))
+	lowest := uint32(0)
+	for i, addr := range addrs {
+		current[i] = weights.get(addr)
+		if i == 0 || current[i].priority < lowest {
+			lowest = current[i].priority
+		}
+	}
+
+	effective := make([]int64, len(addrs))
+	var total int64
+	for i, weight := range current {
+		if weight.priority == lowest {
+			effective[i] = int64(weight.weight)
+			total += effective[i]
+		}
+	}
+	if total == 0 {
+		for i, weight := range current {
+			if weight.priority == lowest {
+				effective[i] = 1
+			}
+		}
+	}
+	return effective
+}
+
+// wrrPicker implements smooth weighted round robin, which interleaves the
+// addresses rather than sending runs of requests to the heaviest ones.
+type wrrPicker struct {
+	subConns []grpcbalancer.SubConn
+	addrs    []string
+	weights  *weightTable
+
+	mu      sync.Mutex
+	current []int64
+}
+
+func newWRRPicker(subConns []grpcbalancer.SubConn, addrs []string, weights *weightTable) grpcbalancer.Picker {
+	return &wrrPicker{
+		subConns: subConns,
+		addrs:    addrs,
+		weights:  weights,
+		current:  make([]int64, len(subConns)),
+	}
+}
+
+func (p *wrrPicker) Pick(grpcbalancer.PickInfo) (grpcbalancer.PickResult, error) {
+	effective := effectiveWeights(p.addrs, p.weights)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	chosen := -1
+	var total int64
+	for i, weight := range effective {
+		if weight == 0 {
+			continue
+		}
+		p.current[i] += weight
+		total += weight
+		if chosen == -1 || p.current[i] > p.current[chosen] {
+			chosen = i
+		}
+	}
+	p.current[chosen] -= total
+
+	return grpcbalancer.PickResult{SubConn: p.subConns[chosen]}, nil
+}
+
+// leastRequestPicker implements the power of two choices: it samples two
+// addresses by weight and picks the one with fewer outstanding requests.
+type leastRequestPicker struct {
+	subConns []grpcbalancer.SubConn
+	addrs    []string
+	weights  *weightTable
+
+	outstanding []int64
+}
+
+func newLeastRequestPicker(subConns []grpcbalancer.SubConn, addrs []string, weights *weightTable) grpcbalancer.Picker {
+	return &leastRequestPicker{
+		subConns:    subConns,
+		addrs:       addrs,
+		weights:     weights,
+		outstanding: make([]int64, len(subConns)),
+	}
+}
+
+func (p *leastRequestPicker) Pick(grpcbalancer.PickInfo) (grpcbalancer.PickResult, error) {
+	effective := effectiveWeights(p.addrs, p.weights)
+
+	chosen := weightedSample(effective)
+	if other := weightedSample(effective); atomic.LoadInt64(&p.outstanding[other]) < atomic.LoadInt64(&p.outstanding[chosen]) {
+		chosen = other
+	}
+
+	atomic.AddInt64(&p.outstanding[chosen], 1)
+	return grpcbalancer.PickResult{
+		SubConn: p.subConns[chosen],
+		Done: func(grpcbalancer.DoneInfo) {
+			atomic.AddInt64(&p.outstanding[chosen], -1)
+		},
+	}, nil
+}
+
+// weightedSample returns a random index with a probability proportional to
+// its weight. At least one of the weights must be positive.
+func weightedSample(weights []int64) int {
+	var total int64
+	for _, weight := range weights {
+		total += weight
+	}
+
+	n := rand.Int63n(total)
+	for i, weight := range weights {
+		if n < weight {
+			return i
+		}
+		n -= weight
+	}
+	panic("unreachable")
+}
@@ -0,0 +1,248 @@
+package balancer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	grpcbalancer "google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	grpcresolver "google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+
+	"github.com/authzed/servok/pkg/resolver"
+)
+
+func TestEffectiveWeights(t *testing.T) {
+	testCases := []struct {
+		name     string
+		weights  map[string]endpointWeight
+		expected []int64
+	}{
+		{
+			"weights",
+			map[string]endpointWeight{"a": {weight: 1}, "b": {weight: 3}, "c": {weight: 0}},
+			[]int64{1, 3, 0},
+		},
+		{
+			"lowest priority tier",
+			map[string]endpointWeight{"a": {weight: 1, priority: 20}, "b": {weight: 3, priority: 10}, "c": {weight: 5, priority: 10}},
+			[]int64{0, 3, 5},
+		},
+		{
+			"all zero",
+			map[string]endpointWeight{"a": {weight: 0}, "b": {weight: 0}, "c": {weight: 0, priority: 10}},
+			[]int64{1, 1, 0},
+		},
+		{
+			"untagged",
+			map[string]endpointWeight{"b": {weight: 3}},
+			[]int64{1, 3, 1},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			weights := &weightTable{}
+			weights.value.Store(tc.weights)
+			require.Equal(t, tc.expected, effectiveWeights([]string{"a", "b", "c"}, weights))
+		})
+	}
+}
+
+type fakeSubConn struct {
+	grpcbalancer.SubConn
+	name string
+}
+
+func TestWRRPicker(t *testing.T) {
+	require := require.New(t)
+
+	subConns := []grpcbalancer.SubConn{&fakeSubConn{name: "a"}, &fakeSubConn{name: "b"}, &fakeSubConn{name: "c"}}
+	weights := &weightTable{}
+	weights.value.Store(map[string]endpointWeight{"a": {weight: 5}, "b": {weight: 1}, "c": {weight: 1}})
+	picker := newWRRPicker(subConns, []string{"a", "b", "c"}, weights)
+
+	var picked string
+	for i := 0; i < 7; i++ {
+		result, err := picker.Pick(grpcbalancer.PickInfo{})
+		require.NoError(err)
+		picked += result.SubConn.(*fakeSubConn).name
+	}
+
+	// Smooth weighted round robin interleaves the lighter addresses
+	require.Equal("aabacaa", picked)
+}
+
+func TestLeastRequestPicker(t *testing.T) {
+	require := require.New(t)
+
+	subConns := []grpcbalancer.SubConn{&fakeSubConn{name: "a"}, &fakeSubConn{name: "b"}}
+	weights := &weightTable{}
+	weights.value.Store(map[string]endpointWeight{"a": {weight: 1}, "b": {weight: 1}})
+	picker := newLeastRequestPicker(subConns, []string{"a", "b"}, weights)
+
+	// Whenever both addresses are sampled, the one with nothing outstanding
+	// is picked, so requests that never finish are spread between them.
+	picked := map[string]int{}
+	for i := 0; i < 100; i++ {
+		result, err := picker.Pick(grpcbalancer.PickInfo{})
+		require.NoError(err)
+		picked[result.SubConn.(*fakeSubConn).name]++
+	}
+	require.InDelta(picked["a"], picked["b"], 30)
+
+	// Finished requests are no longer outstanding
+	for i := 0; i < 100; i++ {
+		result, err := picker.Pick(grpcbalancer.PickInfo{})
+		require.NoError(err)
+		result.Done(grpcbalancer.DoneInfo{})
+	}
+	require.Equal([]int64{int64(picked["a"]), int64(picked["b"])}, picker.(*leastRequestPicker).outstanding)
+}
+
+// countingServers starts gRPC health servers and counts the requests that
+// each of them receives.
+type countingServers struct {
+	sync.Mutex
+	addrs  []string
+	counts map[string]int
+}
+
+func startCountingServers(t *testing.T, n int) *countingServers {
+	servers := &countingServers{counts: map[string]int{}}
+	for i := 0; i < n; i++ {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addr := listener.Addr().String()
+
+		server := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			servers.Lock()
+			servers.counts[addr]++
+			servers.Unlock()
+			return handler(ctx, req)
+		}))
+		healthpb.RegisterHealthServer(server, health.NewServer())
+		go func() {
+			_ = server.Serve(listener)
+		}()
+		t.Cleanup(server.Stop)
+
+		servers.addrs = append(servers.addrs, addr)
+	}
+	return servers
+}
+
+func (s *countingServers) reset() map[string]int {
+	s.Lock()
+	defer s.Unlock()
+	counts := s.counts
+	s.counts = map[string]int{}
+	return counts
+}
+
+func dialWithPolicy(t *testing.T, policy string, addrs []grpcresolver.Address) (healthpb.HealthClient, *manual.Resolver) {
+	r := manual.NewBuilderWithScheme("test")
+	r.InitialState(grpcresolver.State{Addresses: addrs})
+
+	conn, err := grpc.Dial("test:///servers",
+		grpc.WithResolvers(r),
+		grpc.WithInsecure(),
+		grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"loadBalancingConfig": [{"%s": {}}]}`, policy)),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return healthpb.NewHealthClient(conn), r
+}
+
+func TestWeightedRoundRobin(t *testing.T) {
+	require := require.New(t)
+
+	servers := startCountingServers(t, 4)
+	addrs := []grpcresolver.Address{
+		resolver.SetEndpoint(grpcresolver.Address{Addr: servers.addrs[0]}, 1, 10),
+		resolver.SetEndpoint(grpcresolver.Address{Addr: servers.addrs[1]}, 2, 10),
+		resolver.SetEndpoint(grpcresolver.Address{Addr: servers.addrs[2]}, 3, 10),
+		resolver.SetEndpoint(grpcresolver.Address{Addr: servers.addrs[3]}, 100, 20),
+	}
+	client, r := dialWithPolicy(t, WeightedRoundRobinName, addrs)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	check := func() {
+		_, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+		require.NoError(err)
+	}
+
+	// Wait for every server in the lowest priority tier to be connected
+	require.Eventually(func() bool {
+		check()
+		servers.Lock()
+		defer servers.Unlock()
+		return len(servers.counts) == 3
+	}, 5*time.Second, 1*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	servers.reset()
+
+	for i := 0; i < 600; i++ {
+		check()
+	}
+	counts := servers.reset()
+	require.InDelta(100, counts[servers.addrs[0]], 10)
+	require.InDelta(200, counts[servers.addrs[1]], 10)
+	require.InDelta(300, counts[servers.addrs[2]], 10)
+	require.Zero(counts[servers.addrs[3]])
+
+	// Weights take effect without the addresses changing
+	addrs[0] = resolver.SetEndpoint(addrs[0], 0, 10)
+	r.UpdateState(grpcresolver.State{Addresses: addrs})
+	time.Sleep(50 * time.Millisecond)
+	servers.reset()
+
+	for i := 0; i < 500; i++ {
+		check()
+	}
+	counts = servers.reset()
+	require.Zero(counts[servers.addrs[0]])
+	require.InDelta(200, counts[servers.addrs[1]], 10)
+	require.InDelta(300, counts[servers.addrs[2]], 10)
+}
+
+func TestLeastRequest(t *testing.T) {
+	require := require.New(t)
+
+	servers := startCountingServers(t, 2)
+	client, _ := dialWithPolicy(t, LeastRequestName, []grpcresolver.Address{
+		resolver.SetEndpoint(grpcresolver.Address{Addr: servers.addrs[0]}, 1, 0),
+		resolver.SetEndpoint(grpcresolver.Address{Addr: servers.addrs[1]}, 9, 0),
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	check := func() {
+		_, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+		require.NoError(err)
+	}
+
+	require.Eventually(func() bool {
+		check()
+		servers.Lock()
+		defer servers.Unlock()
+		return len(servers.counts) == 2
+	}, 5*time.Second, 1*time.Millisecond)
+	servers.reset()
+
+	// Sequential requests are never outstanding, so they follow the weights
+	for i := 0; i < 500; i++ {
+		check()
+	}
+	counts := servers.reset()
+	require.Greater(counts[servers.addrs[1]], 3*counts[servers.addrs[0]])
+}
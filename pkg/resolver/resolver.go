@@ -0,0 +1,286 @@
+// Package resolver implements a gRPC name resolver that watches the endpoints
+// of targets with a servok server.
+//
+// Targets name the endpoints to watch in their path, such as
+// servok:///srv/grpc/tcp/example.com, as described by sources.ParseTarget:
+//
+//	servok:///srv/<service>/<protocol>/<dns name>
+//	servok:///kubernetes/<namespace>/<service name>[/<port name>]
+//	servok:///file/<name>
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/attributes"
+	grpcresolver "google.golang.org/grpc/resolver"
+
+	v1 "github.com/authzed/servok/internal/proto/servok/api/v1"
+	"github.com/authzed/servok/internal/sources"
+)
+
+// Scheme is the scheme of the targets resolved by servok.
+const Scheme = "servok"
+
+type attributeKey string
+
+const (
+	weightKey   = attributeKey("weight")
+	priorityKey = attributeKey("priority")
+)
+
+// Weight returns the weight of the endpoint that the address belongs to, if
+// it was resolved by servok.
+func Weight(addr grpcresolver.Address) (uint32, bool) {
+	return attribute(addr, weightKey)
+}
+
+// Priority returns the priority of the endpoint that the address belongs to,
+// if it was resolved by servok.
+func Priority(addr grpcresolver.Address) (uint32, bool) {
+	return attribute(addr, priorityKey)
+}
+
+// SetEndpoint returns a copy of the address carrying the weight and priority
+// of its endpoint, as it would have been resolved by servok.
+func SetEndpoint(addr grpcresolver.Address, weight, priority uint32) grpcresolver.Address {
+	if addr.Attributes == nil {
+		addr.Attributes = attributes.New(weightKey, weight, priorityKey, priority)
+	} else {
+		addr.Attributes = addr.Attributes.WithValues(weightKey, weight, priorityKey, priority)
+	}
+	return addr
+}
+
+func attribute(addr grpcresolver.Address, key attributeKey) (uint32, bool) {
+	if addr.Attributes == nil {
+		return 0, false
+	}
+	value, ok := addr.Attributes.Value(key).(uint32)
+	return value, ok
+}
+
+// Options configures how a Builder's resolvers reconnect to servok.
+type Options struct {
+	// InitialBackoff is how long to wait before reconnecting a failed
+	// watch, defaulting to 1s. It doubles after every consecutive failure,
+	// up to MaxBackoff.
+	InitialBackoff time.Duration
+
+	// MaxBackoff defaults to 2m.
+	MaxBackoff time.Duration
+}
+
+// Builder builds resolvers that watch their targets over a connection to a
+// servok server.
+type Builder struct {
+	client v1.EndpointServiceClient
+	opts   Options
+}
+
+// NewBuilder creates a Builder that watches targets over the connection,
+// which should not itself use the servok scheme.
+func NewBuilder(conn grpc.ClientConnInterface, opts Options) *Builder {
+	if opts.InitialBackoff <= 0 {
+		opts.InitialBackoff = 1 * time.Second
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = 2 * time.Minute
+	}
+	return &Builder{client: v1.NewEndpointServiceClient(conn), opts: opts}
+}
+
+// Register creates a Builder for the connection and registers it globally.
+func Register(conn grpc.ClientConnInterface, opts Options) {
+	grpcresolver.Register(NewBuilder(conn, opts))
+}
+
+func (b *Builder) Scheme() string {
+	return Scheme
+}
+
+func (b *Builder) Build(target grpcresolver.Target, cc grpcresolver.ClientConn, _ grpcresolver.BuildOptions) (grpcresolver.Resolver, error) {
+	request, err := sources.ParseTarget(target.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid servok target: %w", err)
+	}
+	request.Delta = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &servokResolver{
+		client:  b.client,
+		opts:    b.opts,
+		cc:      cc,
+		request: request,
+		cancel:  cancel,
+		now:     make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	go r.run(ctx)
+	return r, nil
+}
+
+type servokResolver struct {
+	client  v1.EndpointServiceClient
+	opts    Options
+	cc      grpcresolver.ClientConn
+	request *v1.WatchRequest
+	cancel  context.CancelFunc
+	now     chan struct{}
+	done    chan struct{}
+
+	// endpoints are the last known endpoints of the target, and are only
+	// used by the run goroutine.
+	endpoints []*v1.Endpoint
+}
+
+// ResolveNow reconnects immediately if the watch is waiting to reconnect.
+func (r *servokResolver) ResolveNow(grpcresolver.ResolveNowOptions) {
+	select {
+	case r.now <- struct{}{}:
+	default:
+	}
+}
+
+func (r *servokResolver) Close() {
+	r.cancel()
+	<-r.done
+}
+
+func (r *servokResolver) run(ctx context.Context) {
+	defer close(r.done)
+
+	failures := 0
+	for ctx.Err() == nil {
+		received, err := r.watch(ctx)
+		if ctx.Err() != nil {
+			break
+		}
+		if received {
+			failures = 0
+		}
+		failures++
+
+		// Once endpoints have been resolved they keep being served while
+		// reconnecting, so errors are only reported before that.
+		if r.endpoints == nil {
+			r.cc.ReportError(err)
+		}
+
+		delay := r.backoff(failures)
+		log.Warn().Err(err).Stringer("retryIn", delay).Msg("servok watch failed, reconnecting")
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+		case <-r.now:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// watch streams updates into the ClientConn until the stream fails, and
+// returns whether any were received. Reconnects resume from the last
+// revision that was received.
+func (r *servokResolver) watch(ctx context.Context) (bool, error) {
+	stream, err := r.client.Watch(ctx, r.request)
+	if err != nil {
+		return false, err
+	}
+
+	received := false
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			return received, err
+		}
+		received = true
+		r.request.LastSeenRevision = resp.Revision
+
+		// Sources that have never resolved any endpoints report errors
+		// instead, which must not replace the endpoints already in use.
+		if resp.SourceStatus == v1.WatchResponse_SOURCE_STATUS_ERROR {
+			log.Warn().Str("error", resp.SourceError).Msg("servok source is failing")
+			if r.endpoints == nil {
+				r.cc.ReportError(fmt.Errorf("servok source is failing: %s", resp.SourceError))
+			}
+			continue
+		}
+
+		r.endpoints = applyResponse(r.endpoints, resp)
+
+		if err := r.cc.UpdateState(grpcresolver.State{Addresses: addresses(r.endpoints)}); err != nil {
+			log.Debug().Err(err).Msg("servok resolver state was rejected")
+		}
+	}
+}
+
+func (r *servokResolver) backoff(failures int) time.Duration {
+	delay := r.opts.InitialBackoff
+	for i := 1; i < failures && delay < r.opts.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > r.opts.MaxBackoff {
+		delay = r.opts.MaxBackoff
+	}
+	return delay
+}
+
+// applyResponse returns the endpoints after the response, which is either a
+// snapshot that replaces them or a delta that updates them.
+func applyResponse(endpoints []*v1.Endpoint, resp *v1.WatchResponse) []*v1.Endpoint {
+	if resp.ResponseType != v1.WatchResponse_RESPONSE_TYPE_DELTA {
+		return append([]*v1.Endpoint{}, resp.Endpoints...)
+	}
+
+	changed := map[string]*v1.Endpoint{}
+	for _, endpoint := range resp.Modified {
+		changed[endpointKey(endpoint)] = endpoint
+	}
+	for _, endpoint := range resp.Removed {
+		changed[endpointKey(endpoint)] = nil
+	}
+
+	updated := make([]*v1.Endpoint, 0, len(endpoints)+len(resp.Added))
+	for _, endpoint := range endpoints {
+		replacement, ok := changed[endpointKey(endpoint)]
+		if !ok {
+			replacement = endpoint
+		}
+		if replacement != nil {
+			updated = append(updated, replacement)
+		}
+	}
+	return append(updated, resp.Added...)
+}
+
+func endpointKey(endpoint *v1.Endpoint) string {
+	return fmt.Sprintf("%s:%d", endpoint.Hostname, endpoint.Port)
+}
+
+// addresses converts endpoints into addresses, with one per IP address when
+// they have been resolved and otherwise one for the hostname.
+func addresses(endpoints []*v1.Endpoint) []grpcresolver.Address {
+	addrs := []grpcresolver.Address{}
+	for _, endpoint := range endpoints {
+		port := strconv.FormatUint(uint64(endpoint.Port), 10)
+
+		hosts := endpoint.Addresses
+		if len(hosts) == 0 {
+			hosts = []string{strings.TrimSuffix(endpoint.Hostname, ".")}
+		}
+		for _, host := range hosts {
+			addr := grpcresolver.Address{Addr: net.JoinHostPort(host, port)}
+			addrs = append(addrs, SetEndpoint(addr, endpoint.Weight, endpoint.Priority))
+		}
+	}
+	return addrs
+}
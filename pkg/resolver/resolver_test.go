@@ -0,0 +1,155 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	grpcresolver "google.golang.org/grpc/resolver"
+
+	v1 "github.com/authzed/servok/internal/proto/servok/api/v1"
+	"github.com/authzed/servok/internal/services"
+	"github.com/authzed/servok/internal/services/servicestest"
+	"github.com/authzed/servok/internal/sources"
+)
+
+type fakeClientConn struct {
+	grpcresolver.ClientConn
+
+	states chan grpcresolver.State
+	errs   chan error
+}
+
+func (cc *fakeClientConn) UpdateState(state grpcresolver.State) error {
+	cc.states <- state
+	return nil
+}
+
+func (cc *fakeClientConn) ReportError(err error) {
+	cc.errs <- err
+}
+
+func newTestConn(t *testing.T, kind sources.Kind) *grpc.ClientConn {
+	return servicestest.Dial(t, kind, func(ctx context.Context, registry *sources.Registry, server *grpc.Server) error {
+		servicer, err := services.NewEndpointServicer(ctx, registry, services.Options{RevisionHistory: 4})
+		if err != nil {
+			return err
+		}
+		v1.RegisterEndpointServiceServer(server, servicer)
+		return nil
+	})
+}
+
+func TestAddresses(t *testing.T) {
+	require := require.New(t)
+
+	addrs := addresses([]*v1.Endpoint{
+		{Hostname: "host1.example.com.", Port: 50051, Weight: 5, Priority: 10},
+		{Hostname: "host2.example.com.", Port: 50051, Weight: 1, Addresses: []string{"10.0.0.2", "fd00::2"}},
+	})
+
+	var hosts []string
+	for _, addr := range addrs {
+		hosts = append(hosts, addr.Addr)
+	}
+	require.Equal([]string{"host1.example.com:50051", "10.0.0.2:50051", "[fd00::2]:50051"}, hosts)
+
+	weight, ok := Weight(addrs[0])
+	require.True(ok)
+	require.Equal(uint32(5), weight)
+	priority, ok := Priority(addrs[0])
+	require.True(ok)
+	require.Equal(uint32(10), priority)
+
+	_, ok = Weight(grpcresolver.Address{Addr: "host3:50051"})
+	require.False(ok)
+}
+
+func TestResolver(t *testing.T) {
+	require := require.New(t)
+
+	kind := &servicestest.Kind{}
+	builder := NewBuilder(newTestConn(t, kind), Options{InitialBackoff: 1 * time.Millisecond})
+	require.Equal("servok", builder.Scheme())
+
+	_, err := builder.Build(grpcresolver.Target{Scheme: Scheme, Endpoint: "bogus"}, &fakeClientConn{}, grpcresolver.BuildOptions{})
+	require.Error(err)
+
+	cc := &fakeClientConn{states: make(chan grpcresolver.State), errs: make(chan error)}
+	r, err := builder.Build(grpcresolver.Target{Scheme: Scheme, Endpoint: "srv/grpc/tcp/example.com"}, cc, grpcresolver.BuildOptions{})
+	require.NoError(err)
+	defer r.Close()
+
+	expectHosts := func(expected ...string) {
+		select {
+		case state := <-cc.states:
+			var hosts []string
+			for _, addr := range state.Addresses {
+				hosts = append(hosts, addr.Addr)
+			}
+			require.ElementsMatch(expected, hosts)
+		case <-time.After(1 * time.Second):
+			require.Fail("timed out waiting for state")
+		}
+	}
+
+	require.Eventually(func() bool { return len(kind.Sources()) == 1 }, 1*time.Second, 1*time.Millisecond)
+	source := kind.Sources()[0].Updates
+
+	host1 := &v1.Endpoint{Hostname: "host1", Port: 50051, Weight: 1}
+	host2 := &v1.Endpoint{Hostname: "host2", Port: 50051, Weight: 1}
+	host3 := &v1.Endpoint{Hostname: "host3", Port: 50051, Weight: 1}
+
+	source <- []*v1.Endpoint{host1, host2}
+	expectHosts("host1:50051", "host2:50051")
+
+	// Deltas are applied to the last known endpoints
+	source <- []*v1.Endpoint{host1, host2, host3}
+	expectHosts("host1:50051", "host2:50051", "host3:50051")
+	source <- []*v1.Endpoint{host1, host3}
+	expectHosts("host1:50051", "host3:50051")
+
+	// When the source closes the resolver reconnects to a new one without
+	// reporting an error, since it keeps serving the last known endpoints
+	close(source)
+	require.Eventually(func() bool { return len(kind.Sources()) == 2 }, 1*time.Second, 1*time.Millisecond)
+	kind.Sources()[1].Updates <- []*v1.Endpoint{host2}
+	expectHosts("host2:50051")
+}
+
+func TestResolverSourceErrors(t *testing.T) {
+	require := require.New(t)
+
+	kind := &servicestest.Kind{}
+	builder := NewBuilder(newTestConn(t, kind), Options{InitialBackoff: 1 * time.Millisecond})
+
+	cc := &fakeClientConn{states: make(chan grpcresolver.State), errs: make(chan error)}
+	r, err := builder.Build(grpcresolver.Target{Scheme: Scheme, Endpoint: "srv/grpc/tcp/example.com"}, cc, grpcresolver.BuildOptions{})
+	require.NoError(err)
+	defer r.Close()
+
+	require.Eventually(func() bool { return len(kind.Sources()) == 1 }, 1*time.Second, 1*time.Millisecond)
+
+	// A source that has never resolved reports an error rather than an empty
+	// set of addresses
+	kind.Sources()[0].Errs <- errors.New("server misbehaving")
+	select {
+	case err := <-cc.errs:
+		require.Contains(err.Error(), "server misbehaving")
+	case state := <-cc.states:
+		require.Failf("unexpected state", "%v", state)
+	case <-time.After(1 * time.Second):
+		require.Fail("timed out waiting for error")
+	}
+
+	kind.Sources()[0].Updates <- []*v1.Endpoint{{Hostname: "host1", Port: 50051}}
+	select {
+	case state := <-cc.states:
+		require.Len(state.Addresses, 1)
+	case <-time.After(1 * time.Second):
+		require.Fail("timed out waiting for state")
+	}
+}